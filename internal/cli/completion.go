@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+// completeTheme offers the theme names, including those declared in the
+// configuration file. A value that matches none of them, such as a path,
+// is completed as a .toml or .json theme file.
+func completeTheme(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := []string{}
+	for _, name := range theme.Names() {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"toml", "json"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePath offers the paths of the children of the value that the
+// expression typed so far leads to, read from the files given before. Each
+// suggestion is described by its type. URLs are skipped: completing must
+// not send requests.
+func completePath(opts options, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	files := []string{}
+	for _, arg := range args {
+		if !isURL(arg) {
+			files = append(files, arg)
+		}
+	}
+	// Without a file the document would come from stdin, which is not
+	// there while completing.
+	if len(files) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	opts.path, opts.schemaFile = "", ""
+	docs, err := loadDocuments(files, opts)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	base, _ := parser.SplitLast(toComplete)
+	if base == "" {
+		base = "$"
+	}
+	node, err := parser.Find(docs[0].root, base)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	paths := []string{}
+	for _, child := range node.Children {
+		if path := child.Path(); strings.HasPrefix(path, toComplete) {
+			paths = append(paths, path+"\t"+string(child.Type))
+		}
+	}
+	return paths, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
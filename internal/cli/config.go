@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/simota/jv/internal/config"
+	"github.com/simota/jv/internal/theme"
+	"github.com/simota/jv/internal/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// setting is the effective value of one flag and where it came from.
+type setting struct {
+	name   string
+	value  string
+	source string
+}
+
+// loadConfig reads the configuration file, registers its themes and checks
+// that every value belongs to a known command, flag or TUI action.
+func loadConfig(root *cobra.Command) (*config.Config, error) {
+	cfg, err := config.Load(config.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	for name, fields := range cfg.Themes {
+		if err := theme.Define(name, fields); err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.Path, err)
+		}
+	}
+	if err := tui.CheckKeys(cfg.Keys); err != nil {
+		return nil, fmt.Errorf("%s: %w", cfg.Path, err)
+	}
+	for name := range cfg.Values[""] {
+		if !hasFlag(root, name) {
+			return nil, fmt.Errorf("%s: unknown option %q", cfg.Path, name)
+		}
+	}
+	for _, section := range cfg.Commands() {
+		sub := subcommand(root, section)
+		if sub == nil {
+			return nil, fmt.Errorf("%s: unknown section [%s]", cfg.Path, section)
+		}
+		for name := range cfg.Values[section] {
+			if !hasFlag(sub, name) {
+				return nil, fmt.Errorf("%s: unknown option %q in [%s]", cfg.Path, name, section)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+func subcommand(root *cobra.Command, name string) *cobra.Command {
+	for _, sub := range root.Commands() {
+		if sub.Name() == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func hasFlag(c *cobra.Command, name string) bool {
+	return name != "help" && c.Flags().Lookup(name) != nil
+}
+
+// configure fills in the flags of c that were not given on the command
+// line. The first of these wins:
+//
+//	JV_<COMMAND>_<FLAG>   e.g. JV_DIFF_FORMAT, for subcommands only
+//	JV_<FLAG>             e.g. JV_THEME or JV_NO_INTERACTIVE
+//	[<command>] <flag>    in the configuration file
+//	<flag>                at the top of the configuration file
+//
+// JV_<FLAG> and top-level values are flags of jv itself. They also apply
+// to a subcommand flag shared with jv, that is with the same name and
+// type, such as color and theme, but not to validate's --schema file or
+// diff's --format.
+//
+// It returns the resulting value and source of every flag.
+func configure(c *cobra.Command, cfg *config.Config) ([]setting, error) {
+	command := ""
+	if c.HasParent() {
+		command = c.Name()
+	}
+	settings := []setting{}
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Name == "help" {
+			return
+		}
+		s := setting{name: f.Name, value: f.Value.String(), source: "default"}
+		if f.Changed {
+			s.source = "flag"
+			settings = append(settings, s)
+			return
+		}
+		value, source, ok := lookupDefault(cfg, command, f.Name, sharedFlag(c, f))
+		if ok {
+			if setErr := setFlag(f, value); setErr != nil {
+				where := source
+				if where == "config file" {
+					where = cfg.Path
+				}
+				err = fmt.Errorf("%s: invalid value %q for --%s: %w", where, configString(value), f.Name, setErr)
+				return
+			}
+			s.value, s.source = f.Value.String(), source
+		}
+		settings = append(settings, s)
+	})
+	return settings, err
+}
+
+// setFlag sets f from an environment or configuration value. A TOML array
+// given to a repeatable flag such as --header sets one value per element,
+// as if the flag had been repeated.
+func setFlag(f *pflag.Flag, value any) error {
+	list, isList := value.([]any)
+	slice, isSlice := f.Value.(pflag.SliceValue)
+	if !isList || !isSlice {
+		return f.Value.Set(configString(value))
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		items = append(items, configString(item))
+	}
+	return slice.Replace(items)
+}
+
+// sharedFlag reports whether f, a flag of c, is also a flag of jv itself
+// with the same meaning, judged by its type.
+func sharedFlag(c *cobra.Command, f *pflag.Flag) bool {
+	if !c.HasParent() {
+		return true
+	}
+	own := c.Root().Flags().Lookup(f.Name)
+	return own != nil && own.Value.Type() == f.Value.Type()
+}
+
+// lookupDefault finds the default for a flag of command; the values for
+// jv itself are only consulted when shared is set.
+func lookupDefault(cfg *config.Config, command, flag string, shared bool) (value any, source string, ok bool) {
+	name := strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+	vars := []string{}
+	if command != "" {
+		vars = append(vars, "JV_"+strings.ToUpper(command)+"_"+name)
+	}
+	if shared {
+		vars = append(vars, "JV_"+name)
+	}
+	for _, env := range vars {
+		if value, ok := os.LookupEnv(env); ok {
+			return value, "env " + env, true
+		}
+	}
+	if v, ok := cfg.Values[command][flag]; ok {
+		return v, "config file", true
+	}
+	if v, ok := cfg.Values[""][flag]; ok && shared {
+		return v, "config file", true
+	}
+	return "", "", false
+}
+
+// configString renders a TOML value the way it would be written on the
+// command line; arrays become comma-separated lists.
+func configString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case []any:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, configString(item))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+func newConfigCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Print the effective configuration and where each value comes from",
+		Long:  "Print the effective configuration: the value of every flag and key binding and whether it comes from the environment (JV_*), the configuration file or the defaults.\nThe configuration file is $JV_CONFIG, or jv/config.toml under $XDG_CONFIG_HOME (~/.config).",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return runConfig(cmd, cfg)
+		},
+	}
+}
+
+func runConfig(cmd *cobra.Command, cfg *config.Config) error {
+	out := cmd.OutOrStdout()
+	status := ""
+	if !cfg.Exists {
+		status = " (not found)"
+	}
+	fmt.Fprintf(out, "Config file: %s%s\n\n", cfg.Path, status)
+
+	root := cmd.Root()
+	commands := []*cobra.Command{root}
+	for _, sub := range root.Commands() {
+		if sub != cmd && sub.Flags().HasAvailableFlags() {
+			commands = append(commands, sub)
+		}
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FLAG\tVALUE\tSOURCE")
+	for _, c := range commands {
+		settings, err := configure(c, cfg)
+		if err != nil {
+			return err
+		}
+		prefix := ""
+		if c != root {
+			prefix = c.Name() + "."
+		}
+		for _, s := range settings {
+			fmt.Fprintf(w, "%s%s\t%s\t%s\n", prefix, s.name, s.value, s.source)
+		}
+	}
+	fmt.Fprintln(w)
+	writeKeys(w, cfg)
+	return ignoreBrokenPipe(w.Flush())
+}
+
+func writeKeys(w io.Writer, cfg *config.Config) {
+	fmt.Fprintln(w, "KEY\tBINDING\tSOURCE")
+	for _, action := range tui.ActionNames() {
+		keys, source := tui.DefaultKeys(action), "default"
+		if custom, ok := cfg.Keys[action]; ok {
+			keys, source = custom, "config file"
+		}
+		fmt.Fprintf(w, "keys.%s\t%s\t%s\n", action, strings.Join(keys, ", "), source)
+	}
+}
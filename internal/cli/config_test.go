@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+depth = 3
+theme = "light"
+schema = true
+header = ["A: 1", "B: x,y"]
+
+[diff]
+format = "side-by-side"
+`
+
+// effective parses args for the named command, "" for jv itself, applies
+// testConfig and the environment, and returns the resulting settings.
+func effective(t *testing.T, command string, args ...string) map[string]setting {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JV_CONFIG", path)
+	root := newRootCmd()
+	c := root
+	if command != "" {
+		c = subcommand(root, command)
+	}
+	if err := c.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := configure(c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]setting{}
+	for _, s := range settings {
+		out[s.name] = s
+	}
+	return out
+}
+
+func expect(t *testing.T, settings map[string]setting, name, value, source string) {
+	t.Helper()
+	if s := settings[name]; s.value != value || s.source != source {
+		t.Errorf("%s = %q from %s, want %q from %s", name, s.value, s.source, value, source)
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	expect(t, effective(t, ""), "depth", "3", "config file")
+
+	t.Setenv("JV_DEPTH", "4")
+	expect(t, effective(t, ""), "depth", "4", "env JV_DEPTH")
+	expect(t, effective(t, "", "--depth", "5"), "depth", "5", "flag")
+}
+
+func TestConfigArrays(t *testing.T) {
+	expect(t, effective(t, ""), "header", `[A: 1,"B: x,y"]`, "config file")
+}
+
+func TestSubcommandConfig(t *testing.T) {
+	settings := effective(t, "diff")
+	expect(t, settings, "format", "side-by-side", "config file")
+	// Shared with jv: same name and type.
+	expect(t, settings, "theme", "light", "config file")
+
+	t.Setenv("JV_THEME", "dark")
+	t.Setenv("JV_FORMAT", "auto")
+	settings = effective(t, "diff")
+	expect(t, settings, "theme", "dark", "env JV_THEME")
+	// JV_FORMAT is not a flag of jv, so it does not reach diff.
+	expect(t, settings, "format", "side-by-side", "config file")
+
+	t.Setenv("JV_DIFF_FORMAT", "json-patch")
+	t.Setenv("JV_DIFF_THEME", "gruvbox")
+	settings = effective(t, "diff")
+	expect(t, settings, "format", "json-patch", "env JV_DIFF_FORMAT")
+	expect(t, settings, "theme", "gruvbox", "env JV_DIFF_THEME")
+	expect(t, effective(t, "diff", "--format", "paths"), "format", "paths", "flag")
+}
+
+func TestUnsharedFlag(t *testing.T) {
+	// jv's --schema is a bool; validate's is a file name.
+	t.Setenv("JV_SCHEMA", "true")
+	expect(t, effective(t, "validate"), "schema", "", "default")
+	expect(t, effective(t, ""), "schema", "true", "env JV_SCHEMA")
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		keys string
+		err  string
+	}{
+		{`copy = ["j"]`, `key "j" is bound to both down and copy`},
+		{"copy = [\"y\"]\nstats = [\"y\"]", `key "y" is bound to both copy and stats`},
+		{`next_change = ["ctrl+c"]`, `key "ctrl+c" is bound to both quit and next_change`},
+		{`toggle = ["space", " "]`, ""},
+		// next_change is only bound in jv diff -i, copy only in the viewer.
+		{`copy = ["n"]`, ""},
+		{"down = [\"down\"]\ncopy = [\"j\"]", ""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		if err := os.WriteFile(path, []byte("[keys]\n"+tt.keys+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("JV_CONFIG", path)
+		_, err := loadConfig(newRootCmd())
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: err = %v, want %q", tt.keys, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/simota/jv/internal/config"
+	"github.com/simota/jv/internal/diff"
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/patch"
+	"github.com/simota/jv/internal/pipe"
+	"github.com/simota/jv/internal/theme"
+	"github.com/simota/jv/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+type diffOptions struct {
+	format      string
+	arrayKey    string
+	color       string
+	interactive bool
+	theme       string
+	keys        map[string][]string
+}
+
+// Exit codes follow diff(1): 0 when the documents are equal, 1 when they
+// differ and 2 when they could not be compared.
+const (
+	diffExitDifferent = 1
+	diffExitTrouble   = 2
+)
+
+func newDiffCmd(cfg *config.Config) *cobra.Command {
+	opts := diffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff [OPTIONS] OLD NEW",
+		Short: "Show structural differences between two JSON documents",
+		Long:  "Show structural differences between two JSON documents.\nUse - to read one of them from stdin. Exits 0 when equal, 1 when different and 2 on error.",
+		Args:  diffArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			opts.keys = cfg.Keys
+			return runDiff(cmd, opts, args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.format, "format", "f", "paths", "Output format (paths/side-by-side/json-patch/merge-patch)")
+	cmd.Flags().StringVar(&opts.arrayKey, "array-key", "", "Match array elements by this object field instead of by index")
+	cmd.Flags().StringVarP(&opts.color, "color", "c", "auto", "Color (auto/always/never)")
+	cmd.Flags().BoolVarP(&opts.interactive, "interactive", "i", false, "Browse the differences in the TUI")
+	cmd.Flags().StringVar(&opts.theme, "theme", "dark", "Theme name ("+strings.Join(theme.Names(), "/")+") or a .toml/.json theme file")
+	_ = cmd.RegisterFlagCompletionFunc("theme", completeTheme)
+
+	return cmd
+}
+
+// diffArgs accepts an old and a new document, at most one of them read
+// from stdin. Usage errors exit with diffExitTrouble, since 1 would claim
+// that the documents differ.
+func diffArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+		return &exitError{code: diffExitTrouble, err: err}
+	}
+	if args[0] == "-" && args[1] == "-" {
+		return &exitError{code: diffExitTrouble, err: errors.New("only one of OLD and NEW can be - (stdin)")}
+	}
+	return nil
+}
+
+func runDiff(cmd *cobra.Command, opts diffOptions, oldFile, newFile string) error {
+	if opts.format != "paths" && opts.format != "side-by-side" && opts.format != "json-patch" && opts.format != "merge-patch" {
+		return &exitError{code: diffExitTrouble, err: fmt.Errorf("invalid diff format: %s", opts.format)}
+	}
+	if opts.color != "auto" && opts.color != "always" && opts.color != "never" {
+		return &exitError{code: diffExitTrouble, err: fmt.Errorf("invalid color mode: %s", opts.color)}
+	}
+	selectedTheme, err := theme.Load(opts.theme)
+	if err != nil {
+		return &exitError{code: diffExitTrouble, err: err}
+	}
+	oldRoot, err := readDocument(oldFile)
+	if err != nil {
+		return &exitError{code: diffExitTrouble, err: err}
+	}
+	newRoot, err := readDocument(newFile)
+	if err != nil {
+		return &exitError{code: diffExitTrouble, err: err}
+	}
+
+	if opts.interactive {
+		err := tui.RunDiff(oldRoot, newRoot, tui.DiffOptions{
+			Theme:        opts.theme,
+			ColorEnabled: decideColorEnabled(opts.color, true),
+			Profile:      theme.DetectProfile(),
+			ArrayKey:     opts.arrayKey,
+			Keys:         opts.keys,
+			OldName:      oldFile,
+			NewName:      newFile,
+		})
+		if err != nil {
+			return &exitError{code: diffExitTrouble, err: err}
+		}
+		return nil
+	}
+
+	aligned := diff.Align(oldRoot, newRoot, diff.Options{ArrayKey: opts.arrayKey})
+	if opts.format == "json-patch" || opts.format == "merge-patch" {
+		return writePatch(cmd, opts, selectedTheme, aligned)
+	}
+	changes := aligned.List()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	color := newColorizer(decideColorEnabled(opts.color, false), selectedTheme)
+	var output string
+	switch opts.format {
+	case "side-by-side":
+		output = diff.SideBySide(changes, oldFile, newFile, color, terminalWidth())
+	default:
+		output = diff.Paths(changes, oldFile, newFile, color)
+	}
+	if _, err := io.WriteString(cmd.OutOrStdout(), output); ignoreBrokenPipe(err) != nil {
+		return &exitError{code: diffExitTrouble, err: err}
+	}
+	cmd.SilenceErrors = true
+	return &exitError{code: diffExitDifferent}
+}
+
+// writePatch prints a patch from the old to the new document. An empty
+// patch is still printed so that scripts always get a valid document.
+func writePatch(cmd *cobra.Command, opts diffOptions, t *theme.Theme, aligned *diff.Pair) error {
+	var value any = patch.JSONPatch(aligned)
+	if opts.format == "merge-patch" {
+		var err error
+		if value, err = patch.MergePatch(aligned); err != nil {
+			return &exitError{code: diffExitTrouble, err: err}
+		}
+	}
+	formatter := pipe.NewPrettyFormatter(newColorizer(decideColorEnabled(opts.color, false), t))
+	if err := ignoreBrokenPipe(formatter.Format(cmd.OutOrStdout(), parser.FromValue(value))); err != nil {
+		return &exitError{code: diffExitTrouble, err: err}
+	}
+	if aligned.Changes() == 0 {
+		return nil
+	}
+	cmd.SilenceErrors = true
+	return &exitError{code: diffExitDifferent}
+}
+
+// readDocument reads and parses a JSON file; "-" reads stdin.
+func readDocument(file string) (*parser.Node, error) {
+	if file == "-" {
+		file = ""
+	}
+	data, err := readInput(file)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(bytes.NewReader(data))
+}
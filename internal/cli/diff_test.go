@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runJVDiff runs jv diff without colors, since runJV passes flags diff
+// does not have.
+func runJVDiff(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv("JV_CONFIG", filepath.Join(t.TempDir(), "config.toml"))
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetArgs(append([]string{"diff", "-c", "never"}, args...))
+	cmd.SetOut(&out)
+	cmd.SetErr(io.Discard)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestDiffStdinTwice(t *testing.T) {
+	_, err := runJVDiff(t, "-", "-")
+	if exitCode(err) != diffExitTrouble || !strings.Contains(err.Error(), "only one of OLD and NEW") {
+		t.Fatalf("err = %v (exit %d)", err, exitCode(err))
+	}
+	if _, err := runJVDiff(t, "-"); exitCode(err) != diffExitTrouble {
+		t.Fatalf("err = %v (exit %d)", err, exitCode(err))
+	}
+}
+
+func TestDiffPaths(t *testing.T) {
+	dir := t.TempDir()
+	oldFile, newFile := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	if err := os.WriteFile(oldFile, []byte(`{"a":1,"b":[1]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newFile, []byte(`{"a":2,"b":[1,"x"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runJVDiff(t, oldFile, newFile)
+	if exitCode(err) != diffExitDifferent {
+		t.Fatalf("err = %v", err)
+	}
+	want := "--- " + oldFile + "\n+++ " + newFile + "\n- $.a: 1\n+ $.a: 2\n+ $.b[1]: \"x\"\n"
+	if out != want {
+		t.Fatalf("got\n%s\nwant\n%s", out, want)
+	}
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/pipe"
+)
+
+// fetchTimeout bounds a whole request, including reading the body. It is
+// a variable so that tests can shorten it.
+var fetchTimeout = 60 * time.Second
+
+func isURL(arg string) bool {
+	return strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://")
+}
+
+func hasURL(args []string) bool {
+	for _, arg := range args {
+		if isURL(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch requests url with the --method, --header and --data options and
+// returns the body with a tree of the response status and headers. Any
+// status is returned; a body that is not JSON is reported by the parser
+// along with the status.
+func fetch(url string, opts options) ([]byte, *parser.Node, error) {
+	method := strings.ToUpper(opts.method)
+	var body io.Reader
+	if opts.data != "" {
+		data, err := requestBody(opts.data)
+		if err != nil {
+			return nil, nil, err
+		}
+		body = strings.NewReader(data)
+		if method == "" {
+			method = http.MethodPost
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "jv")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, header := range opts.headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, nil, fmt.Errorf("invalid header %q (expected 'Name: value')", header)
+		}
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, &exitError{code: exitNoInput, err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, &exitError{code: exitNoInput, err: fmt.Errorf("%s: %w", url, err)}
+	}
+	return data, responseNode(method, url, resp), nil
+}
+
+// requestBody returns the --data value, read from a file when it starts
+// with @, as in curl.
+func requestBody(data string) (string, error) {
+	file, ok := strings.CutPrefix(data, "@")
+	if !ok {
+		return data, nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", &exitError{code: exitNoInput, err: err}
+	}
+	return string(content), nil
+}
+
+// responseNode describes a response as a tree, shown above the document in
+// the TUI. Repeated headers are joined with commas.
+func responseNode(method, url string, resp *http.Response) *parser.Node {
+	headers := map[string]any{}
+	for name, values := range resp.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	return parser.FromValue(map[string]any{
+		"method":     method,
+		"url":        url,
+		"proto":      resp.Proto,
+		"status":     resp.StatusCode,
+		"statusText": strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)+" "),
+		"headers":    headers,
+	})
+}
+
+// writeResponse prints the status line and headers of a response before
+// the document, like curl -i.
+func writeResponse(w io.Writer, color pipe.Colorizer, response *parser.Node) error {
+	var b strings.Builder
+	b.WriteString(color.Header(responseField(response, "proto")+" "+responseStatus(response)) + "\n")
+	if headers, err := parser.Find(response, "headers"); err == nil {
+		for _, h := range headers.Children {
+			b.WriteString(color.Key(h.Key) + ": " + fmt.Sprint(h.Value) + "\n")
+		}
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// responseStatus returns the status of a response, e.g. "404 Not Found".
+func responseStatus(response *parser.Node) string {
+	return responseField(response, "status") + " " + responseField(response, "statusText")
+}
+
+func responseField(response *parser.Node, name string) string {
+	if n, err := parser.Find(response, name); err == nil && n.Value != nil {
+		return fmt.Sprint(n.Value)
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runJV executes jv with args and no configuration file, returning stdout.
+func runJV(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv("JV_CONFIG", filepath.Join(t.TempDir(), "config.toml"))
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetArgs(append([]string{"-n", "-c", "never"}, args...))
+	cmd.SetOut(&out)
+	cmd.SetErr(io.Discard)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func exitCode(err error) int {
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	return exitFailure
+}
+
+func TestFetchRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPut || r.Header.Get("X-Token") != "abc" || string(body) != `{"a":1}` {
+			t.Errorf("got %s %q with body %q", r.Method, r.Header.Get("X-Token"), body)
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	out, err := runJV(t, "-X", "put", "-H", "X-Token: abc", "--data", `{"a":1}`, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "{\n  \"ok\": true\n}\n" {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestFetchNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/text" {
+			http.Error(w, "upstream down", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"no such user"}`))
+	}))
+	defer srv.Close()
+
+	// A JSON error body is a document like any other.
+	out, err := runJV(t, srv.URL+"/users/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"no such user"`) {
+		t.Errorf("got %q", out)
+	}
+
+	// Anything else is a parse error that names the status.
+	_, err = runJV(t, srv.URL+"/text")
+	if exitCode(err) != exitParse || !strings.Contains(err.Error(), "502 Bad Gateway") {
+		t.Errorf("got exit %d, %v", exitCode(err), err)
+	}
+}
+
+func TestFetchInclude(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("X-Tag", "a")
+		w.Header().Add("X-Tag", "b")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`[1]`))
+	}))
+	defer srv.Close()
+
+	out, err := runJV(t, "--include", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, body, ok := strings.Cut(out, "\n\n")
+	if !ok {
+		t.Fatalf("no blank line after headers in %q", out)
+	}
+	for _, want := range []string{"HTTP/1.1 201 Created\n", "Content-Type: application/json", "X-Tag: a, b"} {
+		if !strings.Contains(head, want) {
+			t.Errorf("headers %q lack %q", head, want)
+		}
+	}
+	if body != "[\n  1\n]\n" {
+		t.Errorf("body %q", body)
+	}
+
+	// Without --include only the document is printed.
+	if out, _ := runJV(t, srv.URL); strings.Contains(out, "HTTP/") {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+	defer func(timeout time.Duration) { fetchTimeout = timeout }(fetchTimeout)
+	fetchTimeout = 50 * time.Millisecond
+
+	_, err := runJV(t, srv.URL)
+	if exitCode(err) != exitNoInput || !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("got exit %d, %v", exitCode(err), err)
+	}
+}
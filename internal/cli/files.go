@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/pipe"
+	"github.com/simota/jv/internal/tui"
+)
+
+// document is one input file, parsed. samples holds every document in the
+// file for formats that infer their output from several of them. response
+// describes the HTTP response when the input is a URL.
+type document struct {
+	name     string
+	samples  []*parser.Node
+	root     *parser.Node
+	invalid  map[*parser.Node]string
+	response *parser.Node
+}
+
+// wrap prefixes err with the file name when there are several files.
+func (d document) wrap(err error, files int) error {
+	if files > 1 {
+		return fmt.Errorf("%s: %w", d.name, err)
+	}
+	return err
+}
+
+// loadDocuments reads the files and URLs named by args, or stdin when there
+// are none, then combines them with --slurp and selects --path in each.
+func loadDocuments(args []string, opts options) ([]document, error) {
+	files, err := expandFiles(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	docs := make([]document, 0, len(files))
+	for _, file := range files {
+		doc := document{name: file}
+		input := file
+		if file == "-" {
+			input = ""
+		}
+		var data []byte
+		if isURL(file) {
+			data, doc.response, err = fetch(file, opts)
+		} else {
+			data, err = readInput(input)
+		}
+		if err != nil {
+			return nil, err
+		}
+		doc.samples, err = parseInput(data, opts)
+		if err != nil && doc.response != nil {
+			return nil, &exitError{code: exitParse, err: fmt.Errorf("%s (%s): %w", file, responseStatus(doc.response), err)}
+		}
+		if err != nil {
+			return nil, &exitError{code: exitParse, err: doc.wrap(err, len(files))}
+		}
+		doc.root = doc.samples[0]
+		if opts.schemaFile != "" {
+			doc.invalid, err = schemaErrors(opts.schemaFile, doc.root)
+			if err != nil {
+				return nil, err
+			}
+		}
+		docs = append(docs, doc)
+	}
+
+	if opts.slurp {
+		docs, err = slurp(docs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.path != "" {
+		for i := range docs {
+			selected, err := parser.Find(docs[i].root, opts.path)
+			if err != nil {
+				return nil, &exitError{code: exitNoResult, err: docs[i].wrap(err, len(docs))}
+			}
+			docs[i].root = parser.Reroot(selected)
+		}
+	}
+	return docs, nil
+}
+
+// expandFiles expands glob patterns that the shell passed through, such as
+// quoted ones or any pattern on Windows. URLs and arguments naming an
+// existing file are taken literally.
+func expandFiles(args []string) ([]string, error) {
+	files := []string{}
+	for _, arg := range args {
+		if isURL(arg) || !strings.ContainsAny(arg, "*?[") {
+			files = append(files, arg)
+			continue
+		}
+		if _, err := os.Stat(arg); err == nil {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, &exitError{code: exitNoInput, err: fmt.Errorf("no files match %s", arg)}
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// slurp combines the documents into one object keyed by file name.
+func slurp(docs []document) ([]document, error) {
+	names := make([]string, 0, len(docs))
+	roots := make([]*parser.Node, 0, len(docs))
+	invalid := map[*parser.Node]string{}
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		if seen[doc.name] {
+			return nil, fmt.Errorf("%s: given more than once", doc.name)
+		}
+		seen[doc.name] = true
+		names = append(names, doc.name)
+		roots = append(roots, doc.root)
+		for node, msg := range doc.invalid {
+			invalid[node] = msg
+		}
+	}
+	root := parser.Combine(names, roots)
+	return []document{{name: "slurp", samples: []*parser.Node{root}, root: root, invalid: invalid}}, nil
+}
+
+func tuiDocuments(docs []document) []tui.Document {
+	views := make([]tui.Document, 0, len(docs))
+	for _, doc := range docs {
+		views = append(views, tui.Document{Name: doc.name, Root: doc.root, Invalid: doc.invalid, Response: doc.response})
+	}
+	return views
+}
+
+// checkDocuments runs the formatter's check, if any, on every document.
+func checkDocuments(formatter pipe.Formatter, docs []document) error {
+	checker, ok := formatter.(pipe.Checker)
+	if !ok {
+		return nil
+	}
+	for _, doc := range docs {
+		if err := checker.Check(doc.root); err != nil {
+			return doc.wrap(err, len(docs))
+		}
+	}
+	return nil
+}
+
+// writeDocuments formats each document, preceded by a `==> name <==`
+// header when there are several. Formats that infer their output from
+// samples see the documents of every file together.
+func writeDocuments(w io.Writer, formatter pipe.Formatter, color pipe.Colorizer, docs []document, opts options) error {
+	if sampler, ok := formatter.(pipe.SampleFormatter); ok && opts.path == "" {
+		samples := []*parser.Node{}
+		for _, doc := range docs {
+			samples = append(samples, doc.samples...)
+		}
+		if len(samples) > 1 {
+			return sampler.FormatSamples(w, samples)
+		}
+	}
+	for i, doc := range docs {
+		if len(docs) > 1 {
+			header := color.Header("==> "+doc.name+" <==") + "\n"
+			if i > 0 {
+				header = "\n" + header
+			}
+			if _, err := io.WriteString(w, header); err != nil {
+				return err
+			}
+		}
+		if opts.include && doc.response != nil {
+			if err := writeResponse(w, color, doc.response); err != nil {
+				return err
+			}
+		}
+		if err := formatter.Format(w, doc.root); err != nil {
+			return doc.wrap(err, len(docs))
+		}
+	}
+	return nil
+}
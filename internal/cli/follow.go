@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/pipe"
+	"github.com/simota/jv/internal/theme"
+	"github.com/simota/jv/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// followInterval is how often --follow checks the file for new records.
+const followInterval = 250 * time.Millisecond
+
+// follower reads the JSON lines appended to a file. An incomplete last
+// line is kept until the rest of it is written.
+type follower struct {
+	path    string
+	opened  bool
+	offset  int64
+	partial []byte
+	// line counts the lines read so far, for error messages.
+	line int
+}
+
+// lines returns the complete lines written since the last call. A file
+// that shrank, as after log rotation, is read again from the start.
+func (f *follower) lines() ([][]byte, error) {
+	file, err := os.Open(f.path)
+	if errors.Is(err, fs.ErrNotExist) && f.opened {
+		// Rotated away; the new file is read from its start.
+		f.offset, f.partial = 0, nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	f.opened = true
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < f.offset {
+		f.offset, f.partial = 0, nil
+	}
+	if info.Size() == f.offset {
+		return nil, nil
+	}
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	f.offset += int64(len(data))
+	data = append(f.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		f.partial = data
+		return nil, nil
+	}
+	f.partial = append([]byte{}, data[end+1:]...)
+	return bytes.Split(data[:end], []byte{'\n'}), nil
+}
+
+// records parses the new lines, skipping blank ones. With --path each
+// record is narrowed to the selected value; records without it are
+// skipped. Lines that are not JSON are reported together in the error.
+func (f *follower) records(path string) ([]*parser.Node, error) {
+	lines, err := f.lines()
+	if err != nil {
+		return nil, err
+	}
+	records := []*parser.Node{}
+	errs := []error{}
+	for _, line := range lines {
+		f.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		record, err := parser.Parse(bytes.NewReader(line))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", f.line, err))
+			continue
+		}
+		if path != "" {
+			selected, err := parser.Find(record, path)
+			if err != nil {
+				continue
+			}
+			record = parser.Reroot(selected)
+		}
+		records = append(records, record)
+	}
+	return records, errors.Join(errs...)
+}
+
+func checkFollowable(args []string, opts options) error {
+	switch {
+	case len(args) != 1 || args[0] == "-":
+		return errors.New("--follow needs exactly one file")
+	case opts.from != "json":
+		return errors.New("--follow reads JSON lines; --from is not supported")
+	case opts.watch || opts.slurp:
+		return errors.New("--follow cannot be combined with --watch or --slurp")
+	}
+	return nil
+}
+
+// runFollow shows the records in file, then the ones appended to it: in
+// the TUI as a growing array, otherwise printed one by one until the reader
+// goes away.
+func runFollow(cmd *cobra.Command, opts options, file string, selectedTheme *theme.Theme, arrayMode pipe.ArrayMode) error {
+	if _, err := os.Stat(file); err != nil {
+		return err
+	}
+	f := &follower{path: file}
+	colorEnabled := decideColorEnabled(opts.color, opts.forceInteractive)
+
+	if opts.forceInteractive {
+		root := &parser.Node{Key: "root", Type: parser.TypeArray}
+		records, pending := f.records(opts.path)
+		for _, record := range records {
+			root.Append(record)
+		}
+		tuiOpts := tui.Options{Depth: opts.depth, Theme: opts.theme, ColorEnabled: colorEnabled, Profile: theme.DetectProfile(), ShowTypes: opts.showType, ShowStats: opts.stats, Keys: opts.keys, Log: opts.logView, LogLevel: opts.minLevel}
+		tuiOpts.WatchInterval = followInterval
+		tuiOpts.Follow = func() ([]*parser.Node, error) {
+			records, err := f.records(opts.path)
+			// Bad lines found before the TUI started are reported with
+			// the first poll.
+			err, pending = errors.Join(pending, err), nil
+			return records, err
+		}
+		return tui.Run([]tui.Document{{Name: file, Root: root}}, tuiOpts)
+	}
+
+	formatter := selectFormatter(opts, newColorizer(colorEnabled, selectedTheme), arrayMode)
+	out := cmd.OutOrStdout()
+	for {
+		records, err := f.records(opts.path)
+		for _, record := range records {
+			err := checkDocuments(formatter, []document{{root: record}})
+			if err == nil {
+				err = formatter.Format(out, record)
+			}
+			if errors.Is(err, syscall.EPIPE) {
+				return nil
+			}
+			if err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+		}
+		time.Sleep(followInterval)
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/simota/jv/internal/logs"
+	"github.com/simota/jv/internal/parser"
+)
+
+// logSettings reads --log-fields and --log-level, either of which turns
+// the log view on. The level is returned as an index into logs.Levels.
+func logSettings(opts options) (*logs.Fields, int, error) {
+	if !opts.log && opts.logFields == "" && opts.logLevel == "" {
+		return nil, 0, nil
+	}
+	switch {
+	case opts.from != "json":
+		return nil, 0, errors.New("--log reads JSON lines; --from is not supported")
+	case opts.to != "json" || opts.table || opts.schema || opts.stats:
+		return nil, 0, errors.New("--log cannot be combined with --to, --table, --schema or --stats")
+	}
+	fields, err := logs.ParseFields(opts.logFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	level := 0
+	if opts.logLevel != "" {
+		level = logs.Rank(logs.NormalizeLevel(opts.logLevel))
+		if level < 0 {
+			return nil, 0, fmt.Errorf("invalid log level: %s (expected %s)", opts.logLevel, strings.Join(logs.Levels, "/"))
+		}
+	}
+	return &fields, level, nil
+}
+
+// logRecords puts the records of a JSON lines file into one array. A file
+// holding a single array is taken as the list of records.
+func logRecords(records []*parser.Node) *parser.Node {
+	if len(records) == 1 && records[0].Type == parser.TypeArray {
+		return records[0]
+	}
+	root := &parser.Node{Key: "root", Type: parser.TypeArray, Expanded: true}
+	for _, record := range records {
+		root.Append(record)
+	}
+	return root
+}
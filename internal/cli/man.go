@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newManCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "man",
+		Short: "Print the manual page",
+		Long:  "Print the manual page in roff format, e.g. jv man > /usr/local/share/man/man1/jv.1",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return ignoreBrokenPipe(writeMan(cmd.OutOrStdout(), cmd.Root()))
+		},
+	}
+}
+
+// writeMan renders jv(1) from the command tree, so that the page lists
+// the same flags and subcommands as --help.
+func writeMan(w io.Writer, root *cobra.Command) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, ".TH JV 1 \"\" \"jv\" \"User Commands\"\n")
+	fmt.Fprintf(b, ".SH NAME\njv \\- %s\n", roff(root.Short))
+	fmt.Fprintf(b, ".SH SYNOPSIS\n")
+	fmt.Fprintf(b, ".B jv\n%s\n", roff(strings.TrimPrefix(root.Use, "jv ")))
+	for _, sub := range manCommands(root) {
+		fmt.Fprintf(b, ".br\n.B jv %s\n", sub.Name())
+		if args := strings.TrimSpace(strings.TrimPrefix(sub.Use, sub.Name())); args != "" {
+			fmt.Fprintf(b, "%s\n", roff(args))
+		}
+	}
+	fmt.Fprintf(b, ".SH DESCRIPTION\n%s\n", paragraphs(root.Long))
+	fmt.Fprintf(b, ".SH OPTIONS\n")
+	writeManFlags(b, root.Flags())
+	fmt.Fprintf(b, ".SH COMMANDS\n")
+	for _, sub := range manCommands(root) {
+		fmt.Fprintf(b, ".SS jv %s\n", roff(sub.Use))
+		about := sub.Long
+		if about == "" {
+			about = sub.Short
+		}
+		fmt.Fprintf(b, "%s\n", paragraphs(about))
+		writeManFlags(b, sub.Flags())
+	}
+	b.WriteString(`.SH ENVIRONMENT
+.TP
+.B JV_<FLAG>, JV_<COMMAND>_<FLAG>
+Default for a flag, e.g. JV_THEME=gruvbox or JV_DIFF_FORMAT=side\-by\-side.
+JV_<FLAG> applies to subcommands only for the flags they share with jv.
+.TP
+.B JV_CONFIG
+Path of the configuration file.
+.TP
+.B JV_PAGER, PAGER
+Pager for output that does not fit the terminal (default: less \-R).
+It is run by sh \-c with LESS=FRX and LV=\-c unless they are set.
+.SH FILES
+.TP
+.I $XDG_CONFIG_HOME/jv/config.toml
+Configuration file, ~/.config/jv/config.toml by default. Run
+.B jv config
+to see the effective settings.
+`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func manCommands(root *cobra.Command) []*cobra.Command {
+	commands := []*cobra.Command{}
+	for _, sub := range root.Commands() {
+		if sub.IsAvailableCommand() {
+			commands = append(commands, sub)
+		}
+	}
+	return commands
+}
+
+func writeManFlags(b *strings.Builder, flags *pflag.FlagSet) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Name == "help" {
+			return
+		}
+		b.WriteString(".TP\n")
+		if f.Shorthand != "" {
+			fmt.Fprintf(b, "\\fB\\-%s\\fR, ", f.Shorthand)
+		}
+		fmt.Fprintf(b, "\\fB\\-\\-%s\\fR", roff(f.Name))
+		name, usage := pflag.UnquoteUsage(f)
+		if name != "" {
+			fmt.Fprintf(b, " \\fI%s\\fR", roff(name))
+		}
+		b.WriteString("\n")
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "[]" {
+			usage += " (default " + f.DefValue + ")"
+		}
+		fmt.Fprintf(b, "%s\n", roff(usage))
+	})
+}
+
+// paragraphs escapes text and starts a new paragraph at each line break.
+func paragraphs(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = roff(line)
+	}
+	return strings.Join(lines, "\n.PP\n")
+}
+
+// roff escapes text for a roff text line: backslashes, hyphens, which
+// would otherwise become typographic dashes, and a leading control
+// character.
+func roff(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
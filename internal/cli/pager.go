@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// pagerCommand returns $JV_PAGER, $PAGER or less -R, which passes colors
+// through. An empty value or "cat" turns paging off, as in git.
+func pagerCommand() string {
+	for _, env := range []string{"JV_PAGER", "PAGER"} {
+		if value, ok := os.LookupEnv(env); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return "less -R"
+}
+
+// pager is a running pager process reading jv's output.
+type pager struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+}
+
+// startPager runs command through the shell, as git does, with jv's stdout
+// as its terminal. LESS and LV default to git's settings so that less and
+// lv pass colors through. It returns nil when paging is turned off or the
+// pager cannot be started, in which case the output is written directly.
+func startPager(command string) *pager {
+	if command == "" || command == "cat" {
+		return nil
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = os.Environ()
+	for name, value := range map[string]string{"LESS": "FRX", "LV": "-c"} {
+		if _, ok := os.LookupEnv(name); !ok {
+			cmd.Env = append(cmd.Env, name+"="+value)
+		}
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		return nil
+	}
+	return &pager{cmd: cmd, in: in}
+}
+
+func (p *pager) Write(b []byte) (int, error) {
+	return p.in.Write(b)
+}
+
+// Close ends the pager's input and waits for the user to quit it.
+func (p *pager) Close() error {
+	p.in.Close()
+	return p.cmd.Wait()
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/patch"
+	"github.com/simota/jv/internal/pipe"
+	"github.com/spf13/cobra"
+)
+
+type patchOptions struct {
+	format string
+	color  string
+}
+
+func newPatchCmd() *cobra.Command {
+	opts := patchOptions{}
+	cmd := &cobra.Command{
+		Use:   "patch [OPTIONS] DOCUMENT PATCH",
+		Short: "Apply a JSON Patch (RFC 6902) or Merge Patch (RFC 7396)",
+		Long:  "Apply a JSON Patch (RFC 6902) or Merge Patch (RFC 7396) and print the result.\nUse - to read one of them from stdin. By default an array is applied as a JSON Patch and an object as a Merge Patch.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return runPatch(cmd, opts, args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.format, "format", "f", "auto", "Patch format (auto/json-patch/merge-patch)")
+	cmd.Flags().StringVarP(&opts.color, "color", "c", "auto", "Color (auto/always/never)")
+
+	return cmd
+}
+
+func runPatch(cmd *cobra.Command, opts patchOptions, docFile, patchFile string) error {
+	if opts.format != "auto" && opts.format != "json-patch" && opts.format != "merge-patch" {
+		return fmt.Errorf("invalid patch format: %s", opts.format)
+	}
+	if opts.color != "auto" && opts.color != "always" && opts.color != "never" {
+		return fmt.Errorf("invalid color mode: %s", opts.color)
+	}
+	doc, err := readValue(docFile)
+	if err != nil {
+		return err
+	}
+	p, err := readValue(patchFile)
+	if err != nil {
+		return err
+	}
+
+	var result any
+	switch opts.format {
+	case "json-patch":
+		result, err = patch.ApplyJSONPatch(doc, p)
+	case "merge-patch":
+		result = patch.ApplyMergePatch(doc, p)
+	default:
+		result, err = patch.Apply(doc, p)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", patchFile, err)
+	}
+
+	formatter := pipe.NewPrettyFormatter(newColorizer(decideColorEnabled(opts.color, false), nil))
+	return ignoreBrokenPipe(formatter.Format(cmd.OutOrStdout(), parser.FromValue(result)))
+}
+
+// readValue reads a JSON file into plain values; "-" reads stdin.
+func readValue(file string) (any, error) {
+	if file == "-" {
+		file = ""
+	}
+	data, err := readInput(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, &exitError{code: exitParse, err: err}
+	}
+	return v, nil
+}
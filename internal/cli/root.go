@@ -6,9 +6,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 
+	"github.com/simota/jv/internal/config"
+	"github.com/simota/jv/internal/logs"
 	"github.com/simota/jv/internal/parser"
 	"github.com/simota/jv/internal/pipe"
+	"github.com/simota/jv/internal/theme"
 	"github.com/simota/jv/internal/tui"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -22,26 +29,120 @@ type options struct {
 	depth               int
 	theme               string
 	color               string
+	path                string
+	table               bool
+	columns             []string
+	to                  string
+	from                string
+	csvArrays           string
+	inferTypes          bool
+	schemaFile          string
+	typeName            string
+	stats               bool
+	statsTop            int
+	noPager             bool
+	slurp               bool
+	watch               bool
+	follow              bool
+	log                 bool
+	logFields           string
+	logLevel            string
+	quiet               bool
+	headers             []string
+	method              string
+	data                string
+	include             bool
+	exitStatus          bool
+	keys                map[string][]string
+	// logView and minLevel are the log view settings read from the log
+	// flags by logSettings.
+	logView  *logs.Fields
+	minLevel int
 }
 
+// exitError carries a specific process exit code. A nil err exits
+// without printing a message.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return "exit status " + strconv.Itoa(e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// Exit codes of jv, validate and patch, so that scripts can tell failures
+// apart. jv diff follows diff(1) instead.
+const (
+	// exitFailure covers usage and other errors, and a null or false
+	// value with --exit-status, as in jq.
+	exitFailure  = 1
+	exitNoInput  = 2 // a file is missing or unreadable
+	exitParse    = 3 // the input is not valid JSON (or CSV)
+	exitNoResult = 4 // --path selects nothing
+	exitInvalid  = 5 // jv validate found schema violations
+)
+
 func Execute() {
+	// Report writes to a closed pipe as EPIPE errors instead of letting the
+	// runtime kill the process, so formatters can stop and jv exits 0.
+	// Unlike signal.Ignore, a handled signal is reset to its default for
+	// child processes, so the pager still dies of SIGPIPE as usual.
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
 	if err := newRootCmd().Execute(); err != nil {
-		os.Exit(1)
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+		os.Exit(exitFailure)
+	}
+}
+
+// ignoreBrokenPipe treats a reader that stopped early, as in
+// `jv huge.json | head`, as success.
+func ignoreBrokenPipe(err error) error {
+	if errors.Is(err, syscall.EPIPE) {
+		return nil
 	}
+	return err
 }
 
 func newRootCmd() *cobra.Command {
 	opts := options{}
+	cfg := &config.Config{}
 	cmd := &cobra.Command{
-		Use:   "jv [OPTIONS] [FILE]",
+		Use:   "jv [OPTIONS] [FILE...]",
 		Short: "JSON viewer for the terminal",
-		Args:  cobra.MaximumNArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			file := ""
-			if len(args) == 1 {
-				file = args[0]
+		Long:  "JSON viewer for the terminal.\nFILE may be a glob such as 'fixtures/*.json' or an http(s) URL; several files are printed one after another, or shown as tabs in interactive mode.",
+		Args:  cobra.ArbitraryArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Completion uses the themes of the configuration file when it
+			// loads, and must keep working when it does not.
+			if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+				if loaded, err := loadConfig(cmd.Root()); err == nil {
+					*cfg = *loaded
+				}
+				return nil
+			}
+			loaded, err := loadConfig(cmd.Root())
+			if err == nil {
+				*cfg = *loaded
+				_, err = configure(cmd, cfg)
 			}
-			return run(cmd, opts, file)
+			// The command line was fine; a usage message would hide the
+			// problem with the configuration.
+			cmd.SilenceUsage = err != nil
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			opts.keys = cfg.Keys
+			return run(cmd, opts, args)
 		},
 	}
 
@@ -50,18 +151,54 @@ func newRootCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&opts.showType, "type", "t", false, "Show type hints")
 	cmd.Flags().BoolVarP(&opts.schema, "schema", "s", false, "Show schema mode")
 	cmd.Flags().IntVarP(&opts.depth, "depth", "d", 2, "Initial expand depth (interactive)")
-	cmd.Flags().StringVar(&opts.theme, "theme", "dark", "Theme (dark/light)")
+	cmd.Flags().StringVar(&opts.theme, "theme", "dark", "Theme name ("+strings.Join(theme.Names(), "/")+") or a .toml/.json theme file")
 	cmd.Flags().StringVarP(&opts.color, "color", "c", "always", "Color (auto/always/never)")
+	cmd.Flags().StringVarP(&opts.path, "path", "q", "", "Select the value at a path (e.g. $.items[0])")
+	cmd.Flags().BoolVar(&opts.table, "table", false, "Show an array of objects as a table")
+	cmd.Flags().StringSliceVar(&opts.columns, "columns", nil, "Table columns to show (comma separated)")
+	cmd.Flags().StringVar(&opts.to, "to", "json", "Output format (json/csv/tsv/yaml/toml/xml/jsonschema/go/typescript/python-dataclass)")
+	cmd.Flags().StringVar(&opts.typeName, "type-name", "Root", "Name of the root type for code generation")
+	cmd.Flags().StringVar(&opts.from, "from", "json", "Input format (json/csv/tsv)")
+	cmd.Flags().StringVar(&opts.csvArrays, "csv-arrays", "json", "Nested arrays in CSV output (json/index/join)")
+	cmd.Flags().BoolVar(&opts.inferTypes, "infer-types", false, "Infer numbers, booleans and null from CSV input")
+	cmd.Flags().StringVar(&opts.schemaFile, "schema-file", "", "Highlight values violating a JSON Schema (interactive)")
+	cmd.Flags().BoolVar(&opts.stats, "stats", false, "Report node counts, depth and the heaviest paths (size panel in interactive mode)")
+	cmd.Flags().IntVar(&opts.statsTop, "stats-top", pipe.DefaultStatsTop, "Number of longest arrays and heaviest paths to report")
+	cmd.Flags().BoolVar(&opts.noPager, "no-pager", false, "Do not page output that does not fit the terminal")
+	cmd.Flags().BoolVar(&opts.slurp, "slurp", false, "Combine the files into one object keyed by file name")
+	cmd.Flags().BoolVar(&opts.watch, "watch", false, "Reload and show the files again whenever they change")
+	cmd.Flags().BoolVarP(&opts.follow, "follow", "f", false, "Show JSON lines appended to a growing file, like tail -f")
+	cmd.Flags().BoolVar(&opts.log, "log", false, "Show JSON lines as log records, one summary line each")
+	cmd.Flags().StringVar(&opts.logFields, "log-fields", "", "Log record fields, e.g. time=ts,level=severity,msg=message (implies --log)")
+	cmd.Flags().StringArrayVarP(&opts.headers, "header", "H", nil, "HTTP header for URL arguments, e.g. 'Authorization: Bearer TOKEN' (repeatable)")
+	cmd.Flags().StringVarP(&opts.method, "method", "X", "", "HTTP method for URL arguments (default GET, or POST with --data)")
+	cmd.Flags().StringVar(&opts.data, "data", "", "Request body for URL arguments; @FILE reads it from a file")
+	cmd.Flags().BoolVar(&opts.include, "include", false, "Print the HTTP status and headers before the document, like curl -i")
+	cmd.Flags().BoolVar(&opts.quiet, "quiet", false, "Only check that the input parses (and --path exists); print nothing")
+	cmd.Flags().BoolVarP(&opts.exitStatus, "exit-status", "e", false, "Exit 1 when the selected value is null or false, like jq -e")
+	cmd.Flags().StringVar(&opts.logLevel, "log-level", "", "Hide log records below this level (trace/debug/info/warn/error/fatal; implies --log)")
+
+	_ = cmd.RegisterFlagCompletionFunc("theme", completeTheme)
+	_ = cmd.RegisterFlagCompletionFunc("path", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completePath(opts, args, toComplete)
+	})
+
+	cmd.AddCommand(newValidateCmd())
+	cmd.AddCommand(newDiffCmd(cfg))
+	cmd.AddCommand(newPatchCmd())
+	cmd.AddCommand(newConfigCmd(cfg))
+	cmd.AddCommand(newManCmd())
 
 	return cmd
 }
 
-func run(cmd *cobra.Command, opts options, file string) error {
+func run(cmd *cobra.Command, opts options, args []string) error {
 	if opts.forceInteractive && opts.forceNonInteractive {
 		return errors.New("cannot use --interactive and --no-interactive together")
 	}
-	if opts.theme != "dark" && opts.theme != "light" {
-		return fmt.Errorf("invalid theme: %s", opts.theme)
+	selectedTheme, err := theme.Load(opts.theme)
+	if err != nil {
+		return err
 	}
 	if opts.color != "auto" && opts.color != "always" && opts.color != "never" {
 		return fmt.Errorf("invalid color mode: %s", opts.color)
@@ -69,48 +206,240 @@ func run(cmd *cobra.Command, opts options, file string) error {
 	if opts.depth < 0 {
 		opts.depth = 0
 	}
-
-	data, err := readInput(file)
+	if !isValidFormat(opts.to, outputFormats) {
+		return fmt.Errorf("invalid output format: %s", opts.to)
+	}
+	if !isValidFormat(opts.from, inputFormats) {
+		return fmt.Errorf("invalid input format: %s", opts.from)
+	}
+	arrayMode, err := pipe.ParseArrayMode(opts.csvArrays)
+	if err != nil {
+		return err
+	}
+	opts.logView, opts.minLevel, err = logSettings(opts)
 	if err != nil {
 		return err
 	}
+	if (opts.quiet || opts.exitStatus) && (opts.watch || opts.follow) {
+		return errors.New("--quiet and --exit-status cannot be combined with --watch or --follow")
+	}
+	if opts.quiet && opts.forceInteractive {
+		return errors.New("cannot use --quiet and --interactive together")
+	}
 
-	root, err := parser.Parse(bytes.NewReader(data))
+	if opts.follow {
+		if err := checkFollowable(args, opts); err != nil {
+			return err
+		}
+		return runFollow(cmd, opts, args[0], selectedTheme, arrayMode)
+	}
+
+	var last string
+	if opts.watch {
+		if err := checkWatchable(args); err != nil {
+			return err
+		}
+		last = snapshot(args)
+	}
+	docs, err := loadDocuments(args, opts)
 	if err != nil {
 		return err
 	}
+	if opts.quiet {
+		return checkExitStatus(cmd, opts, docs)
+	}
 
-	interactive := decideInteractive(opts)
+	interactive := decideInteractive(opts, docs, arrayMode)
 	colorEnabled := decideColorEnabled(opts.color, interactive)
 
 	if interactive {
-		return tui.Run(root, tui.Options{Depth: opts.depth, Theme: opts.theme, ColorEnabled: colorEnabled, ShowTypes: opts.showType})
+		tuiOpts := tui.Options{Depth: opts.depth, Theme: opts.theme, ColorEnabled: colorEnabled, Profile: theme.DetectProfile(), ShowTypes: opts.showType, ShowStats: opts.stats, Keys: opts.keys, Log: opts.logView, LogLevel: opts.minLevel}
+		if opts.watch {
+			tuiOpts.WatchInterval = watchInterval
+			tuiOpts.Reload = func() ([]tui.Document, error) {
+				current := snapshot(args)
+				if current == last {
+					return nil, nil
+				}
+				last = current
+				docs, err := loadDocuments(args, opts)
+				if err != nil {
+					return nil, err
+				}
+				return tuiDocuments(docs), nil
+			}
+		}
+		if hasURL(args) {
+			tuiOpts.Refetch = func() ([]tui.Document, error) {
+				docs, err := loadDocuments(args, opts)
+				if err != nil {
+					return nil, err
+				}
+				return tuiDocuments(docs), nil
+			}
+		}
+		if err := tui.Run(tuiDocuments(docs), tuiOpts); err != nil {
+			return err
+		}
+		return checkExitStatus(cmd, opts, docs)
+	}
+	if opts.schemaFile != "" {
+		return errors.New("--schema-file is only supported in interactive mode; use jv validate --schema")
 	}
 
-	formatter := selectFormatter(opts, colorEnabled)
-	output := formatter.Format(root)
-	_, err = io.WriteString(cmd.OutOrStdout(), output)
-	return err
+	color := newColorizer(colorEnabled, selectedTheme)
+	formatter := selectFormatter(opts, color, arrayMode)
+	if opts.watch {
+		return watchDocuments(cmd, args, opts, last, docs, func(w io.Writer, docs []document) error {
+			if err := checkDocuments(formatter, docs); err != nil {
+				return err
+			}
+			return writeDocuments(w, formatter, color, docs, opts)
+		})
+	}
+	if err := checkDocuments(formatter, docs); err != nil {
+		return err
+	}
+	write := func(w io.Writer) error {
+		return writeDocuments(w, formatter, color, docs, opts)
+	}
+	if !opts.noPager && exceedsScreen(write) {
+		if p := startPager(pagerCommand()); p != nil {
+			err := ignoreBrokenPipe(write(p))
+			if closeErr := p.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			return checkExitStatus(cmd, opts, docs)
+		}
+	}
+	if err := ignoreBrokenPipe(write(cmd.OutOrStdout())); err != nil {
+		return err
+	}
+	return checkExitStatus(cmd, opts, docs)
+}
+
+// checkExitStatus fails silently with exitFailure under --exit-status when
+// the selected value of any document is null or false.
+func checkExitStatus(cmd *cobra.Command, opts options, docs []document) error {
+	if !opts.exitStatus {
+		return nil
+	}
+	for _, doc := range docs {
+		if doc.root.Type == parser.TypeNull || doc.root.Value == false {
+			cmd.SilenceErrors = true
+			return &exitError{code: exitFailure}
+		}
+	}
+	return nil
+}
+
+var (
+	outputFormats = []string{"json", "csv", "tsv", "yaml", "toml", "xml", "jsonschema", "go", "typescript", "python-dataclass"}
+	inputFormats  = []string{"json", "csv", "tsv"}
+	// sampleFormats infer their output from every document in the input
+	// rather than only the first.
+	sampleFormats = []string{"jsonschema", "go", "typescript", "python-dataclass"}
+)
+
+func isValidFormat(format string, formats []string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func parseInput(data []byte, opts options) ([]*parser.Node, error) {
+	var root *parser.Node
+	var err error
+	switch opts.from {
+	case "csv":
+		root, err = parser.ParseCSV(bytes.NewReader(data), ',', opts.inferTypes)
+	case "tsv":
+		root, err = parser.ParseCSV(bytes.NewReader(data), '\t', opts.inferTypes)
+	default:
+		if opts.logView != nil {
+			records, err := parser.ParseAll(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return []*parser.Node{logRecords(records)}, nil
+		}
+		if isValidFormat(opts.to, sampleFormats) {
+			return parser.ParseAll(bytes.NewReader(data))
+		}
+		root, err = parser.Parse(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []*parser.Node{root}, nil
 }
 
 func readInput(file string) ([]byte, error) {
 	if file != "" {
-		return os.ReadFile(file)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, &exitError{code: exitNoInput, err: err}
+		}
+		return data, nil
 	}
 	if !term.IsTerminal(int(os.Stdin.Fd())) {
-		return io.ReadAll(os.Stdin)
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, &exitError{code: exitNoInput, err: err}
+		}
+		return data, nil
 	}
 	return nil, errors.New("no input provided. Try: jv path/to.json | cat file.json | jv | echo '{}' | jv")
 }
 
-func decideInteractive(opts options) bool {
+// decideInteractive opens the TUI when asked to, or when the output is a
+// terminal and the JSON tree would not fit on the screen, like a pager.
+// Other output formats are always written out.
+func decideInteractive(opts options, docs []document, arrayMode pipe.ArrayMode) bool {
 	if opts.forceInteractive {
 		return true
 	}
-	if opts.forceNonInteractive {
+	if opts.forceNonInteractive || opts.to != "json" || opts.table || opts.schema || opts.stats {
 		return false
 	}
-	return false
+	formatter := selectFormatter(opts, pipe.Colorizer{}, arrayMode)
+	return exceedsScreen(func(w io.Writer) error {
+		return writeDocuments(w, formatter, pipe.Colorizer{}, docs, opts)
+	})
+}
+
+// exceedsScreen reports whether stdout is a terminal too short for the
+// output of write. Formatting stops as soon as the answer is known.
+func exceedsScreen(write func(io.Writer) error) bool {
+	height := terminalHeight()
+	if height == 0 {
+		return false
+	}
+	return errors.Is(write(&lineCounter{max: height}), errTooLong)
+}
+
+// errTooLong stops a formatter once its output is known not to fit.
+var errTooLong = errors.New("output longer than the screen")
+
+// lineCounter discards output, failing with errTooLong when it reaches max
+// lines.
+type lineCounter struct {
+	lines int
+	max   int
+}
+
+func (c *lineCounter) Write(p []byte) (int, error) {
+	c.lines += bytes.Count(p, []byte{'\n'})
+	if c.lines >= c.max {
+		return 0, errTooLong
+	}
+	return len(p), nil
 }
 
 func decideColorEnabled(mode string, interactive bool) bool {
@@ -127,12 +456,67 @@ func decideColorEnabled(mode string, interactive bool) bool {
 	}
 }
 
-func selectFormatter(opts options, colorEnabled bool) pipe.Formatter {
+func terminalWidth() int {
+	width, _ := terminalSize()
+	return width
+}
+
+func terminalHeight() int {
+	_, height := terminalSize()
+	return height
+}
+
+// terminalSize returns the size of stdout, or zeros when it is not a
+// terminal.
+func terminalSize() (width, height int) {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0, 0
+	}
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		return 0, 0
+	}
+	return width, height
+}
+
+// newColorizer paints pipe output with t, or the dark theme when t is nil,
+// at the color depth the terminal advertises.
+func newColorizer(enabled bool, t *theme.Theme) pipe.Colorizer {
+	return pipe.Colorizer{Enabled: enabled, Theme: t, Profile: theme.DetectProfile()}
+}
+
+func selectFormatter(opts options, color pipe.Colorizer, arrayMode pipe.ArrayMode) pipe.Formatter {
+	if opts.logView != nil {
+		return pipe.NewLogFormatter(color, *opts.logView, opts.minLevel)
+	}
+	if opts.stats {
+		return pipe.NewStatsFormatter(color, opts.statsTop)
+	}
+	switch opts.to {
+	case "csv":
+		return pipe.NewCSVFormatter(',', arrayMode)
+	case "tsv":
+		return pipe.NewCSVFormatter('\t', arrayMode)
+	case "yaml":
+		return pipe.NewYAMLFormatter(color)
+	case "toml":
+		return pipe.NewTOMLFormatter(color)
+	case "xml":
+		return pipe.NewXMLFormatter(color)
+	case "jsonschema":
+		return pipe.NewJSONSchemaFormatter(color)
+	case "go", "typescript", "python-dataclass":
+		return pipe.NewCodeFormatter(pipe.Language(opts.to), opts.typeName)
+	}
+	if opts.table {
+		return pipe.NewTableFormatter(color, pipe.TableOptions{Columns: opts.columns, Width: terminalWidth()})
+	}
 	if opts.schema {
-		return pipe.NewSchemaFormatter(colorEnabled)
+		return pipe.NewSchemaFormatter(color)
 	}
 	if opts.showType {
-		return pipe.NewTypedFormatter(colorEnabled)
+		return pipe.NewTypedFormatter(color)
 	}
-	return pipe.NewPrettyFormatter(colorEnabled)
+	return pipe.NewPrettyFormatter(color)
 }
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/pipe"
+	"github.com/simota/jv/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+type validateOptions struct {
+	schema string
+	color  string
+}
+
+func newValidateCmd() *cobra.Command {
+	opts := validateOptions{}
+	cmd := &cobra.Command{
+		Use:   "validate --schema SCHEMA [FILE]",
+		Short: "Validate JSON against a JSON Schema (draft 2020-12)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := ""
+			if len(args) == 1 {
+				file = args[0]
+			}
+			cmd.SilenceUsage = true
+			return runValidate(cmd, opts, file)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.schema, "schema", "", "JSON Schema file")
+	cmd.Flags().StringVarP(&opts.color, "color", "c", "auto", "Color (auto/always/never)")
+	_ = cmd.MarkFlagRequired("schema")
+
+	return cmd
+}
+
+func runValidate(cmd *cobra.Command, opts validateOptions, file string) error {
+	if opts.color != "auto" && opts.color != "always" && opts.color != "never" {
+		return fmt.Errorf("invalid color mode: %s", opts.color)
+	}
+	schema, err := loadSchema(opts.schema)
+	if err != nil {
+		return err
+	}
+	data, err := readInput(file)
+	if err != nil {
+		return err
+	}
+	root, err := parser.Parse(bytes.NewReader(data))
+	if err != nil {
+		return &exitError{code: exitParse, err: err}
+	}
+
+	name := file
+	if name == "" {
+		name = "stdin"
+	}
+	violations := schema.Validate(root)
+	color := newColorizer(decideColorEnabled(opts.color, false), nil)
+	out := cmd.OutOrStdout()
+	if len(violations) == 0 {
+		_, err := fmt.Fprintf(out, "%s: valid\n", name)
+		return err
+	}
+	for _, v := range violations {
+		if err := writeViolation(out, color, v); err != nil {
+			return err
+		}
+	}
+	return &exitError{code: exitInvalid, err: fmt.Errorf("%s: %d schema violation(s)", name, len(violations))}
+}
+
+func writeViolation(w io.Writer, color pipe.Colorizer, v validate.Violation) error {
+	_, err := fmt.Fprintf(w, "%s: %s: %s %s\n",
+		color.Key(v.Node.Path()),
+		color.Number(v.Keyword),
+		v.Message,
+		color.TypeHint("("+v.SchemaPath+")"))
+	return err
+}
+
+func loadSchema(path string) (*validate.Schema, error) {
+	if path == "" {
+		return nil, errors.New("no schema provided")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return validate.Compile(f)
+}
+
+// schemaErrors validates root and indexes the violation messages by node
+// for highlighting in the TUI.
+func schemaErrors(path string, root *parser.Node) (map[*parser.Node]string, error) {
+	schema, err := loadSchema(path)
+	if err != nil {
+		return nil, err
+	}
+	errs := map[*parser.Node]string{}
+	for _, v := range schema.Validate(root) {
+		msg := v.Keyword + ": " + v.Message
+		if prev, ok := errs[v.Node]; ok {
+			msg = prev + "; " + msg
+		}
+		errs[v.Node] = msg
+	}
+	return errs, nil
+}
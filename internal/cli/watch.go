@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// watchInterval is how often --watch checks the input files.
+const watchInterval = 500 * time.Millisecond
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// snapshot describes the files named by args by size and modification
+// time, so that polling can tell when one of them changed. Globs are
+// expanded again so that new matches are picked up.
+func snapshot(args []string) string {
+	files, err := expandFiles(args)
+	if err != nil {
+		return err.Error()
+	}
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", file, err)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %d %d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+func checkWatchable(args []string) error {
+	if len(args) == 0 {
+		return errors.New("--watch needs a file to watch; stdin cannot be read again")
+	}
+	for _, arg := range args {
+		if arg == "-" {
+			return errors.New("--watch needs a file to watch; stdin cannot be read again")
+		}
+		if isURL(arg) {
+			return errors.New("--watch needs a file to watch; to fetch a URL again, press r in interactive mode")
+		}
+	}
+	return nil
+}
+
+// watchDocuments prints docs, then waits for the files to change and
+// prints them again, clearing the screen first when stdout is a terminal.
+// Errors in a new version are reported and the previous output stays
+// until the next change. It returns when the reader goes away.
+func watchDocuments(cmd *cobra.Command, args []string, opts options, last string, docs []document, write func(io.Writer, []document) error) error {
+	out := cmd.OutOrStdout()
+	clear := term.IsTerminal(int(os.Stdout.Fd()))
+	var err error
+	for {
+		if err == nil {
+			if clear {
+				if _, err := io.WriteString(out, clearScreen); err != nil {
+					return ignoreBrokenPipe(err)
+				}
+			}
+			err = write(out, docs)
+		}
+		if errors.Is(err, syscall.EPIPE) {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+		}
+
+		current := last
+		for current == last {
+			time.Sleep(watchInterval)
+			current = snapshot(args)
+		}
+		last = current
+		docs, err = loadDocuments(args, opts)
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds the contents of the configuration file:
+//
+//	theme = "gruvbox"
+//	color = "auto"
+//
+//	[diff]
+//	format = "side-by-side"
+//
+//	[keys]
+//	quit = ["q", "esc"]
+//
+//	[themes.mine]
+//	extends = "light"
+//	key = { color = "#005f87", bold = true }
+//
+// Top-level values are defaults for the flags of jv, which subcommands with
+// a flag of the same name share; a table named after a subcommand sets
+// defaults for that subcommand only.
+type Config struct {
+	Path   string
+	Exists bool
+	// Values maps a command name, "" for jv itself, to flag defaults. The
+	// values are TOML values: strings, integers, floats, booleans or arrays.
+	Values map[string]map[string]any
+	// Keys rebinds TUI actions.
+	Keys map[string][]string
+	// Themes are theme file contents by theme name.
+	Themes map[string]map[string]any
+}
+
+// DefaultPath returns $JV_CONFIG when set, otherwise config.toml in the jv
+// directory under $XDG_CONFIG_HOME or ~/.config.
+func DefaultPath() string {
+	if path := os.Getenv("JV_CONFIG"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "jv", "config.toml")
+}
+
+// Load reads the configuration file at path. A missing file is an empty
+// configuration.
+func Load(path string) (*Config, error) {
+	cfg := &Config{
+		Path:   path,
+		Values: map[string]map[string]any{"": {}},
+		Keys:   map[string][]string{},
+		Themes: map[string]map[string]any{},
+	}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg.Exists = true
+
+	raw := map[string]any{}
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.parse(raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) parse(raw map[string]any) error {
+	for _, name := range sortedKeys(raw) {
+		v := raw[name]
+		switch name {
+		case "keys":
+			table, ok := v.(map[string]any)
+			if !ok {
+				return errors.New("keys: expected a table")
+			}
+			for action, keys := range table {
+				list, err := stringList(keys)
+				if err != nil {
+					return fmt.Errorf("keys.%s: %w", action, err)
+				}
+				c.Keys[action] = list
+			}
+		case "themes":
+			table, ok := v.(map[string]any)
+			if !ok {
+				return errors.New("themes: expected a table")
+			}
+			for theme, fields := range table {
+				fields, ok := fields.(map[string]any)
+				if !ok {
+					return fmt.Errorf("themes.%s: expected a table", theme)
+				}
+				c.Themes[theme] = fields
+			}
+		default:
+			if table, ok := v.(map[string]any); ok {
+				c.Values[name] = table
+			} else {
+				c.Values[""][name] = v
+			}
+		}
+	}
+	return nil
+}
+
+// Commands returns the subcommands with a table in the file, sorted.
+func (c *Config) Commands() []string {
+	names := []string{}
+	for name := range c.Values {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func stringList(v any) ([]string, error) {
+	switch val := v.(type) {
+	case string:
+		return []string{val}, nil
+	case []any:
+		list := make([]string, 0, len(val))
+		for _, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a key name, got %v", item)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("expected a key name or a list of key names, got %v", v)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,244 @@
+package diff
+
+import (
+	"math/big"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change is a single difference between two documents. Old is nil for
+// additions and New is nil for removals.
+type Change struct {
+	Kind Kind
+	Old  *parser.Node
+	New  *parser.Node
+}
+
+// Path returns the location of the change, preferring the new document.
+func (c Change) Path() string {
+	if c.New != nil {
+		return c.New.Path()
+	}
+	return c.Old.Path()
+}
+
+type Options struct {
+	// ArrayKey matches array elements that are objects by this field
+	// instead of by index, so reordered records are not reported.
+	ArrayKey string
+}
+
+// Pair aligns a value of the old document with the matching value of the
+// new one. Containers of the same type on both sides are aligned child by
+// child; any other difference is a leaf with a non-empty Kind.
+type Pair struct {
+	Key      string
+	Kind     Kind
+	Old      *parser.Node
+	New      *parser.Node
+	Children []*Pair
+	Parent   *Pair
+	Depth    int
+	Expanded bool
+	changes  int
+}
+
+// Align pairs up both trees.
+func Align(a, b *parser.Node, opts Options) *Pair {
+	c := &comparer{opts: opts}
+	return c.align("root", a, b, nil, 0)
+}
+
+// Compare walks both trees and returns their differences in document
+// order.
+func Compare(a, b *parser.Node, opts Options) []Change {
+	return Align(a, b, opts).List()
+}
+
+// List returns the differences at or below p in document order.
+func (p *Pair) List() []Change {
+	out := []Change{}
+	p.walk(func(p *Pair) {
+		if p.Kind != "" {
+			out = append(out, Change{Kind: p.Kind, Old: p.Old, New: p.New})
+		}
+	})
+	return out
+}
+
+func (p *Pair) walk(fn func(*Pair)) {
+	fn(p)
+	for _, child := range p.Children {
+		child.walk(fn)
+	}
+}
+
+// Changes returns the number of differences at or below p.
+func (p *Pair) Changes() int {
+	return p.changes
+}
+
+// Node returns the new side of the pair, or the old side for removals.
+func (p *Pair) Node() *parser.Node {
+	if p.New != nil {
+		return p.New
+	}
+	return p.Old
+}
+
+func (p *Pair) Path() string {
+	return p.Node().Path()
+}
+
+// IsContainer reports whether p has aligned children.
+func (p *Pair) IsContainer() bool {
+	return p.Kind == "" && len(p.Children) > 0
+}
+
+type comparer struct {
+	opts Options
+}
+
+func (c *comparer) align(key string, a, b *parser.Node, parent *Pair, depth int) *Pair {
+	p := &Pair{Key: key, Old: a, New: b, Parent: parent, Depth: depth}
+	switch {
+	case a == nil:
+		p.Kind = Added
+	case b == nil:
+		p.Kind = Removed
+	case a.Type != b.Type:
+		p.Kind = Changed
+	case a.Type == parser.TypeObject:
+		c.alignObjects(p)
+	case a.Type == parser.TypeArray:
+		if c.opts.ArrayKey != "" && keyed(a, c.opts.ArrayKey) && keyed(b, c.opts.ArrayKey) {
+			c.alignKeyed(p)
+		} else {
+			c.alignIndexed(p)
+		}
+	default:
+		// Numbers are compared by value, so 1.0 and 1 are equal.
+		if !parser.EqualValues(a.ToValue(), b.ToValue()) {
+			p.Kind = Changed
+		}
+	}
+	if p.Kind != "" {
+		p.changes = 1
+	}
+	for _, child := range p.Children {
+		p.changes += child.changes
+	}
+	return p
+}
+
+func (c *comparer) add(p *Pair, key string, a, b *parser.Node) {
+	p.Children = append(p.Children, c.align(key, a, b, p, p.Depth+1))
+}
+
+// alignObjects merges the sorted child keys of both objects.
+func (c *comparer) alignObjects(p *Pair) {
+	a, b := p.Old, p.New
+	i, j := 0, 0
+	for i < len(a.Children) || j < len(b.Children) {
+		switch {
+		case j >= len(b.Children) || (i < len(a.Children) && a.Children[i].Key < b.Children[j].Key):
+			c.add(p, a.Children[i].Key, a.Children[i], nil)
+			i++
+		case i >= len(a.Children) || b.Children[j].Key < a.Children[i].Key:
+			c.add(p, b.Children[j].Key, nil, b.Children[j])
+			j++
+		default:
+			c.add(p, a.Children[i].Key, a.Children[i], b.Children[j])
+			i++
+			j++
+		}
+	}
+}
+
+func (c *comparer) alignIndexed(p *Pair) {
+	a, b := p.Old, p.New
+	for i := 0; i < len(a.Children) || i < len(b.Children); i++ {
+		var oldChild, newChild *parser.Node
+		if i < len(a.Children) {
+			oldChild = a.Children[i]
+		}
+		if i < len(b.Children) {
+			newChild = b.Children[i]
+		}
+		key := ""
+		if newChild != nil {
+			key = newChild.Key
+		} else {
+			key = oldChild.Key
+		}
+		c.add(p, key, oldChild, newChild)
+	}
+}
+
+// alignKeyed walks the old elements in order, pairing each with the new
+// element holding the same key, then appends unmatched new elements.
+func (c *comparer) alignKeyed(p *Pair) {
+	a, b := p.Old, p.New
+	index := map[string]*parser.Node{}
+	for _, child := range b.Children {
+		index[keyValue(child, c.opts.ArrayKey)] = child
+	}
+	matched := map[*parser.Node]bool{}
+	for _, child := range a.Children {
+		other, ok := index[keyValue(child, c.opts.ArrayKey)]
+		if !ok || matched[other] {
+			c.add(p, child.Key, child, nil)
+			continue
+		}
+		matched[other] = true
+		c.add(p, other.Key, child, other)
+	}
+	for _, child := range b.Children {
+		if !matched[child] {
+			c.add(p, child.Key, nil, child)
+		}
+	}
+}
+
+// keyed reports whether every element of array is an object holding a
+// primitive value for key.
+func keyed(array *parser.Node, key string) bool {
+	for _, child := range array.Children {
+		if keyNode(child, key) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func keyNode(node *parser.Node, key string) *parser.Node {
+	if node.Type != parser.TypeObject {
+		return nil
+	}
+	for _, child := range node.Children {
+		if child.Key == key && child.Type != parser.TypeObject && child.Type != parser.TypeArray {
+			return child
+		}
+	}
+	return nil
+}
+
+// keyValue identifies an element by its key field. Numbers are normalized
+// so that ids written as 1 and 1.0 match.
+func keyValue(node *parser.Node, key string) string {
+	k := keyNode(node, key)
+	if k.Type == parser.TypeNumber {
+		if r, ok := new(big.Rat).SetString(k.StringValue()); ok {
+			return string(k.Type) + ":" + r.RatString()
+		}
+	}
+	return string(k.Type) + ":" + k.StringValue()
+}
@@ -0,0 +1,34 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+func parse(t *testing.T, input string) *parser.Node {
+	t.Helper()
+	root, err := parser.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestCompareNumbersByValue(t *testing.T) {
+	a := parse(t, `{"a":1.0,"b":1e2,"c":[0.5],"d":"1"}`)
+	b := parse(t, `{"a":1,"b":100,"c":[5e-1],"d":"1.0"}`)
+	changes := Compare(a, b, Options{})
+	if len(changes) != 1 || changes[0].Path() != "$.d" {
+		t.Fatalf("got %v", changes)
+	}
+}
+
+func TestCompareKeyedNumbers(t *testing.T) {
+	a := parse(t, `[{"id":1,"v":"x"},{"id":2,"v":"y"}]`)
+	b := parse(t, `[{"id":2.0,"v":"y"},{"id":1.0,"v":"x"}]`)
+	if changes := Compare(a, b, Options{ArrayKey: "id"}); len(changes) != 0 {
+		t.Fatalf("got %v", changes)
+	}
+}
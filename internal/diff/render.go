@@ -0,0 +1,108 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/pipe"
+)
+
+const (
+	sideBySideGap = 2
+	// defaultWidth is used for side-by-side output when the terminal width
+	// is unknown.
+	defaultWidth = 120
+)
+
+// Paths renders one line per removed or added value with its path,
+// prefixed with - and +, below ---/+++ headers naming the documents. A
+// changed value produces a - line followed by a + line. There are no hunks
+// or context lines as in a unified diff of text.
+func Paths(changes []Change, oldName, newName string, color pipe.Colorizer) string {
+	var b strings.Builder
+	b.WriteString(color.Removed("--- " + oldName))
+	b.WriteByte('\n')
+	b.WriteString(color.Added("+++ " + newName))
+	b.WriteByte('\n')
+	for _, c := range changes {
+		if c.Old != nil {
+			b.WriteString(color.Removed("- " + c.Old.Path() + ": " + Compact(c.Old)))
+			b.WriteByte('\n')
+		}
+		if c.New != nil {
+			b.WriteString(color.Added("+ " + c.New.Path() + ": " + Compact(c.New)))
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// SideBySide renders a path column followed by the old and new values.
+// width is the total line width; zero selects a default.
+func SideBySide(changes []Change, oldName, newName string, color pipe.Colorizer, width int) string {
+	if width <= 0 {
+		width = defaultWidth
+	}
+	pathWidth := runewidth.StringWidth("PATH")
+	for _, c := range changes {
+		if w := runewidth.StringWidth(c.Path()); w > pathWidth {
+			pathWidth = w
+		}
+	}
+	if pathWidth > width/3 {
+		pathWidth = width / 3
+	}
+	valueWidth := (width - pathWidth - 2*sideBySideGap) / 2
+	if valueWidth < 8 {
+		valueWidth = 8
+	}
+
+	var b strings.Builder
+	writeRow(&b, color.Key, color.Key, color.Key, pathWidth, valueWidth, "PATH", oldName, newName)
+	for _, c := range changes {
+		oldText, newText := "", ""
+		if c.Old != nil {
+			oldText = Compact(c.Old)
+		}
+		if c.New != nil {
+			newText = Compact(c.New)
+		}
+		marker := color.Changed
+		switch c.Kind {
+		case Added:
+			marker = color.Added
+		case Removed:
+			marker = color.Removed
+		}
+		writeRow(&b, marker, color.Removed, color.Added, pathWidth, valueWidth, c.Path(), oldText, newText)
+	}
+	return b.String()
+}
+
+func writeRow(b *strings.Builder, pathColor, oldColor, newColor func(string) string, pathWidth, valueWidth int, path, oldText, newText string) {
+	b.WriteString(pathColor(pad(path, pathWidth)))
+	b.WriteString(strings.Repeat(" ", sideBySideGap))
+	b.WriteString(oldColor(pad(oldText, valueWidth)))
+	b.WriteString(strings.Repeat(" ", sideBySideGap))
+	b.WriteString(newColor(runewidth.Truncate(newText, valueWidth, "…")))
+	b.WriteByte('\n')
+}
+
+func pad(s string, width int) string {
+	s = runewidth.Truncate(s, width, "…")
+	return runewidth.FillRight(s, width)
+}
+
+// Compact renders node as single-line JSON.
+func Compact(node *parser.Node) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(node.ToValue()); err != nil {
+		return node.StringValue()
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,204 @@
+package logs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+// Fields lists the candidate names of the timestamp, level and message of
+// a record. The first one present wins; a dotted name such as log.level
+// also matches nested objects.
+type Fields struct {
+	Time    []string
+	Level   []string
+	Message []string
+}
+
+// DefaultFields covers the common logging libraries: zap, zerolog, logrus,
+// slog, pino, bunyan and the Elastic Common Schema.
+var DefaultFields = Fields{
+	Time:    []string{"time", "timestamp", "ts", "@timestamp", "t", "datetime"},
+	Level:   []string{"level", "lvl", "severity", "log.level", "loglevel"},
+	Message: []string{"msg", "message", "@message", "text", "event"},
+}
+
+// ParseFields reads a --log-fields value such as
+// "time=ts,level=severity,msg=log.message". Fields that are not given keep
+// their default candidates.
+func ParseFields(spec string) (Fields, error) {
+	fields := DefaultFields
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Fields{}, fmt.Errorf("invalid log field %q (expected time=NAME, level=NAME or msg=NAME)", part)
+		}
+		switch name {
+		case "time":
+			fields.Time = []string{value}
+		case "level":
+			fields.Level = []string{value}
+		case "msg", "message":
+			fields.Message = []string{value}
+		default:
+			return Fields{}, fmt.Errorf("invalid log field %q (expected time, level or msg)", name)
+		}
+	}
+	return fields, nil
+}
+
+// Levels are the normalized levels, from least to most severe.
+var Levels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// Rank returns the position of level in Levels, or -1 for records without
+// a recognized level.
+func Rank(level string) int {
+	for i, l := range Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Visible reports whether a record at level passes a filter showing the
+// levels from rank min up. A filter of 0 or less shows every record,
+// including those without a recognized level.
+func Visible(level string, min int) bool {
+	return min <= 0 || Rank(level) >= min
+}
+
+// Record is the one-line summary of a log record.
+type Record struct {
+	Time    string
+	Level   string
+	Message string
+	// Fields are the nodes used for the summary, so that they can be left
+	// out when the rest of the record is shown.
+	Fields map[*parser.Node]bool
+}
+
+// Summarize finds the timestamp, level and message of a record.
+func Summarize(record *parser.Node, f Fields) Record {
+	r := Record{Fields: map[*parser.Node]bool{}}
+	if record.Type != parser.TypeObject {
+		r.Message = Text(record)
+		return r
+	}
+	if n := r.lookup(record, f.Time); n != nil {
+		r.Time = formatTime(n)
+	}
+	if n := r.lookup(record, f.Level); n != nil {
+		r.Level = NormalizeLevel(Text(n))
+	}
+	if n := r.lookup(record, f.Message); n != nil {
+		r.Message = Text(n)
+	}
+	return r
+}
+
+// Text returns a value as it reads in a log line: strings without quotes,
+// everything else as JSON.
+func Text(n *parser.Node) string {
+	if s, ok := n.Value.(string); ok && n.Type == parser.TypeString {
+		return s
+	}
+	return n.StringValue()
+}
+
+func (r *Record) lookup(record *parser.Node, names []string) *parser.Node {
+	for _, name := range names {
+		if n := field(record, name); n != nil && n.Type != parser.TypeObject && n.Type != parser.TypeArray {
+			r.Fields[n] = true
+			return n
+		}
+	}
+	return nil
+}
+
+// field finds name among the members of obj, first as a literal key and
+// then as a dotted path into nested objects.
+func field(obj *parser.Node, name string) *parser.Node {
+	for _, child := range obj.Children {
+		if child.Key == name {
+			return child
+		}
+	}
+	head, rest, ok := strings.Cut(name, ".")
+	if !ok {
+		return nil
+	}
+	for _, child := range obj.Children {
+		if child.Key == head && child.Type == parser.TypeObject {
+			return field(child, rest)
+		}
+	}
+	return nil
+}
+
+// NormalizeLevel maps level names and the numeric levels of pino and
+// bunyan onto Levels. Unknown values are returned lowercased.
+func NormalizeLevel(level string) string {
+	if n, err := strconv.Atoi(level); err == nil {
+		switch {
+		case n <= 10:
+			return "trace"
+		case n <= 20:
+			return "debug"
+		case n <= 30:
+			return "info"
+		case n <= 40:
+			return "warn"
+		case n <= 50:
+			return "error"
+		default:
+			return "fatal"
+		}
+	}
+	switch level = strings.ToLower(level); level {
+	case "trc", "trace":
+		return "trace"
+	case "dbg", "debug":
+		return "debug"
+	case "inf", "info", "information", "notice":
+		return "info"
+	case "wrn", "warn", "warning":
+		return "warn"
+	case "err", "error":
+		return "error"
+	case "ftl", "fatal", "panic", "dpanic", "crit", "critical", "alert", "emerg", "emergency":
+		return "fatal"
+	}
+	return level
+}
+
+// formatTime shows string timestamps as they are and renders Unix times,
+// in seconds or milliseconds, as RFC 3339 in UTC.
+func formatTime(n *parser.Node) string {
+	if n.Type != parser.TypeNumber {
+		return Text(n)
+	}
+	v, err := strconv.ParseFloat(n.StringValue(), 64)
+	if err != nil {
+		return n.StringValue()
+	}
+	if v > 1e12 {
+		v /= 1000
+	}
+	sec, frac := math.Modf(v)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// Label is the level as shown in summaries: upper case, padded to five
+// columns.
+func (r Record) Label() string {
+	return fmt.Sprintf("%-5s", strings.ToUpper(r.Level))
+}
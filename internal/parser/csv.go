@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCSV reads delimited records into an array of objects. The first
+// record is the header; dotted headers such as `address.city` become nested
+// objects, unless a prefix such as `address` is a column of its own, in
+// which case the dotted header stays a literal key. Nested objects whose
+// keys are exactly 0..N-1 (`tags.0`, `tags.1`) become arrays. With
+// inferTypes, cells are converted to numbers, booleans, null and embedded
+// JSON where they parse as such; otherwise every cell is a string.
+func ParseCSV(r io.Reader, comma rune, inferTypes bool) (*Node, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv input is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) > 0 {
+		headers[0] = strings.TrimPrefix(headers[0], "\ufeff")
+	}
+
+	literal := literalHeaders(headers)
+	nested := []string{}
+	for _, header := range headers {
+		if !literal[header] {
+			nested = append(nested, header)
+		}
+	}
+	prefixes := headerPrefixes(nested)
+	rows := []any{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := map[string]any{}
+		for i, header := range headers {
+			cell := ""
+			if i < len(record) {
+				cell = record[i]
+			}
+			var value any = cell
+			if inferTypes {
+				value = inferCell(cell)
+			}
+			if literal[header] {
+				row[header] = value
+			} else {
+				setDotted(row, header, value)
+			}
+		}
+		rows = append(rows, arraysFromIndexKeys(row, prefixes))
+	}
+	return buildNode("root", rows, nil, 0), nil
+}
+
+func inferCell(cell string) any {
+	switch cell {
+	case "", "null":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if isJSONNumber(cell) {
+		return json.Number(cell)
+	}
+	if strings.HasPrefix(cell, "[") || strings.HasPrefix(cell, "{") {
+		dec := json.NewDecoder(bytes.NewReader([]byte(cell)))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err == nil && !dec.More() {
+			return v
+		}
+	}
+	return cell
+}
+
+func isJSONNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	if s[0] != '-' && (s[0] < '0' || s[0] > '9') {
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return false
+	}
+	return json.Valid([]byte(s))
+}
+
+func setDotted(obj map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	cur := obj
+	for i, part := range parts[:len(parts)-1] {
+		next, exists := cur[part]
+		if !exists {
+			child := map[string]any{}
+			cur[part] = child
+			cur = child
+			continue
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			// A scalar already occupies this prefix; keep the literal key.
+			cur[strings.Join(parts[i:], ".")] = value
+			return
+		}
+		cur = child
+	}
+	cur[parts[len(parts)-1]] = value
+}
+
+// literalHeaders returns the dotted headers that would nest under another
+// column, such as `a.b` next to `a`. Splitting them would let one column
+// overwrite the other.
+func literalHeaders(headers []string) map[string]bool {
+	columns := map[string]bool{}
+	for _, header := range headers {
+		columns[header] = true
+	}
+	literal := map[string]bool{}
+	for _, header := range headers {
+		for i, r := range header {
+			if r == '.' && columns[header[:i]] {
+				literal[header] = true
+			}
+		}
+	}
+	return literal
+}
+
+// headerPrefixes returns the paths of the objects that dotted headers
+// build, e.g. "a" and "a.b" for `a.b.c`.
+func headerPrefixes(headers []string) map[string]bool {
+	prefixes := map[string]bool{}
+	for _, header := range headers {
+		for i, r := range header {
+			if r == '.' {
+				prefixes[header[:i]] = true
+			}
+		}
+	}
+	return prefixes
+}
+
+// arraysFromIndexKeys turns the objects built from dotted headers into
+// arrays where their keys are exactly 0..N-1. The row itself and objects
+// decoded from JSON cells stay objects, so headers named 0..N do not make
+// a row an array.
+func arraysFromIndexKeys(row map[string]any, prefixes map[string]bool) map[string]any {
+	for k, child := range row {
+		row[k] = indexedArray(child, k, prefixes)
+	}
+	return row
+}
+
+func indexedArray(v any, path string, prefixes map[string]bool) any {
+	obj, ok := v.(map[string]any)
+	if !ok || !prefixes[path] {
+		return v
+	}
+	for k, child := range obj {
+		obj[k] = indexedArray(child, path+"."+k, prefixes)
+	}
+	if len(obj) == 0 {
+		return obj
+	}
+	indexes := make([]int, 0, len(obj))
+	for k := range obj {
+		idx, err := strconv.Atoi(k)
+		if err != nil || idx < 0 || strconv.Itoa(idx) != k {
+			return obj
+		}
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	for i, idx := range indexes {
+		if i != idx {
+			return obj
+		}
+	}
+	arr := make([]any, len(indexes))
+	for i := range arr {
+		arr[i] = obj[strconv.Itoa(i)]
+	}
+	return arr
+}
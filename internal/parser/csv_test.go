@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseCSVIndexHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"top-level indexes", "0,1\na,b\n", `[{"0":"a","1":"b"}]`},
+		{"nested indexes", "id,tags.0,tags.1\n1,x,y\n", `[{"id":1,"tags":["x","y"]}]`},
+		{"deeply nested", "a.0.b.0,a.0.b.1\n1,2\n", `[{"a":[{"b":[1,2]}]}]`},
+		{"gap", "tags.0,tags.2\nx,y\n", `[{"tags":{"0":"x","2":"y"}}]`},
+		{"prefix column after", "a.b,a\n1,2\n", `[{"a":2,"a.b":1}]`},
+		{"prefix column before", "a,a.b.c,a.d\n1,2,3\n", `[{"a":1,"a.b.c":2,"a.d":3}]`},
+		{"unrelated prefix", "ab,a.b\n1,2\n", `[{"a":{"b":2},"ab":1}]`},
+		{"JSON cell", "obj\n\"{\"\"0\"\":1}\"\n", `[{"obj":{"0":1}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ParseCSV(strings.NewReader(tt.input), ',', true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(root.ToValue())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"regexp"
 	"sort"
 	"strconv"
@@ -42,6 +43,33 @@ func Parse(r io.Reader) (*Node, error) {
 	return root, nil
 }
 
+// ParseAll decodes every JSON value in r, such as concatenated documents
+// or NDJSON, and returns one root per value.
+func ParseAll(r io.Reader) ([]*Node, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	roots := []*Node{}
+	for {
+		var v any
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		roots = append(roots, buildNode("root", v, nil, 0))
+	}
+	if len(roots) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return roots, nil
+}
+
+// FromValue builds a tree from decoded JSON values such as those produced
+// by encoding/json with UseNumber, or by Node.ToValue.
+func FromValue(v any) *Node {
+	return buildNode("root", v, nil, 0)
+}
+
 func buildNode(key string, v any, parent *Node, depth int) *Node {
 	node := &Node{
 		Key:    key,
@@ -139,3 +167,82 @@ func (n *Node) StringValue() string {
 		return strings.TrimSpace(fmt.Sprintf("%v", n.Value))
 	}
 }
+
+// ToValue converts the subtree rooted at n back into plain Go values
+// suitable for encoding/json. Numbers are returned as json.Number.
+func (n *Node) ToValue() any {
+	switch n.Type {
+	case TypeObject:
+		obj := map[string]any{}
+		for _, child := range n.Children {
+			obj[child.Key] = child.ToValue()
+		}
+		return obj
+	case TypeArray:
+		arr := make([]any, 0, len(n.Children))
+		for _, child := range n.Children {
+			arr = append(arr, child.ToValue())
+		}
+		return arr
+	case TypeString:
+		if v, ok := n.Value.(string); ok {
+			return v
+		}
+		return fmt.Sprintf("%v", n.Value)
+	case TypeNumber:
+		return json.Number(n.StringValue())
+	case TypeBoolean:
+		if v, ok := n.Value.(bool); ok {
+			return v
+		}
+		parsed, err := strconv.ParseBool(n.StringValue())
+		if err == nil {
+			return parsed
+		}
+		return n.StringValue()
+	case TypeNull:
+		return nil
+	default:
+		return n.StringValue()
+	}
+}
+
+// EqualValues compares decoded JSON values, treating numbers by value so
+// that 1 and 1.0 are equal.
+func EqualValues(a, b any) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		ar, aok := new(big.Rat).SetString(av.String())
+		br, bok := new(big.Rat).SetString(bv.String())
+		return aok && bok && ar.Cmp(br) == 0
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, item := range av {
+			other, ok := bv[k]
+			if !ok || !EqualValues(item, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !EqualValues(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
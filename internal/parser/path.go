@@ -0,0 +1,222 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type PathSegment struct {
+	Key   string
+	Index int
+	IsKey bool
+}
+
+// ParsePath parses a path expression in the same notation produced by
+// Node.Path, e.g. `$.items[0].name` or `$["key with space"]`. The leading
+// `$` is optional.
+func ParsePath(expr string) ([]PathSegment, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	segments := []PathSegment{}
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(s) && s[i] != '.' && s[i] != '[' {
+				i++
+			}
+			if start == i {
+				if i == len(s) && len(segments) == 0 {
+					return segments, nil
+				}
+				return nil, fmt.Errorf("invalid path %q: empty key at offset %d", expr, start)
+			}
+			segments = append(segments, PathSegment{Key: s[start:i], IsKey: true})
+		case '[':
+			end := closingBracket(s, i)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated bracket at offset %d", expr, i)
+			}
+			inner := s[i+1 : end]
+			i = end + 1
+			if strings.HasPrefix(inner, "\"") {
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: bad quoted key %s", expr, inner)
+				}
+				segments = append(segments, PathSegment{Key: key, IsKey: true})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid path %q: bad index [%s]", expr, inner)
+			}
+			segments = append(segments, PathSegment{Index: idx, Key: inner})
+		default:
+			if len(segments) > 0 || i > 0 {
+				return nil, fmt.Errorf("invalid path %q: unexpected %q at offset %d", expr, s[i], i)
+			}
+			// Allow a bare leading key such as `items[0]`.
+			s = "." + s
+		}
+	}
+	return segments, nil
+}
+
+// SplitLast splits a path expression that may still be being typed before
+// its last segment, e.g. `$.items[0].na` into `$.items[0]` and `.na`. Dots
+// and brackets inside quoted keys do not split it, and an unterminated
+// bracket starts the last segment.
+func SplitLast(expr string) (base, last string) {
+	start := 0
+	if strings.HasPrefix(expr, "$") {
+		start = 1
+	}
+	segment := len(expr)
+	for i := start; i < len(expr); {
+		segment = i
+		if expr[i] == '[' {
+			end := closingBracket(expr, i)
+			if end < 0 {
+				break
+			}
+			i = end + 1
+			continue
+		}
+		i++
+		for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+			i++
+		}
+	}
+	return expr[:segment], expr[segment:]
+}
+
+func closingBracket(s string, open int) int {
+	inQuote := false
+	for i := open + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case ']':
+			if !inQuote {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Find resolves a path expression against root.
+func Find(root *Node, expr string) (*Node, error) {
+	segments, err := ParsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	node := root
+	for _, seg := range segments {
+		next := node.child(seg)
+		if next == nil {
+			return nil, fmt.Errorf("path not found: %s (no %s at %s)", expr, seg.String(), node.Path())
+		}
+		node = next
+	}
+	return node, nil
+}
+
+func (n *Node) child(seg PathSegment) *Node {
+	switch n.Type {
+	case TypeObject:
+		if !seg.IsKey {
+			return nil
+		}
+		for _, child := range n.Children {
+			if child.Key == seg.Key {
+				return child
+			}
+		}
+	case TypeArray:
+		if seg.IsKey || seg.Index >= len(n.Children) {
+			return nil
+		}
+		return n.Children[seg.Index]
+	}
+	return nil
+}
+
+func (s PathSegment) String() string {
+	if !s.IsKey {
+		return "[" + strconv.Itoa(s.Index) + "]"
+	}
+	if isSimpleKey(s.Key) {
+		return "." + s.Key
+	}
+	return "[" + strconv.Quote(s.Key) + "]"
+}
+
+// Reroot detaches n from its parent so it can be displayed as a document
+// root. Depths of n and its descendants are rebased to start at zero.
+func Reroot(n *Node) *Node {
+	if n.Parent == nil {
+		return n
+	}
+	n.Parent = nil
+	n.Key = "root"
+	rebaseDepth(n, 0)
+	return n
+}
+
+// Combine builds an object whose members are the given documents, keyed
+// by name. The roots are attached as they are, so nodes keep their
+// identity.
+func Combine(names []string, roots []*Node) *Node {
+	obj := &Node{Key: "root", Type: TypeObject, Expanded: true}
+	order := make([]int, len(roots))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return names[order[a]] < names[order[b]] })
+	for _, i := range order {
+		root := roots[i]
+		root.Key = names[i]
+		root.Parent = obj
+		rebaseDepth(root, 1)
+		obj.Children = append(obj.Children, root)
+	}
+	return obj
+}
+
+// Append adds root as the last element of the array n, as when records
+// arrive from a growing log.
+func (n *Node) Append(root *Node) {
+	root.Key = strconv.Itoa(len(n.Children))
+	root.Parent = n
+	rebaseDepth(root, n.Depth+1)
+	n.Children = append(n.Children, root)
+}
+
+func rebaseDepth(n *Node, depth int) {
+	n.Depth = depth
+	for _, child := range n.Children {
+		rebaseDepth(child, depth+1)
+	}
+}
+
+// Pointer returns the RFC 6901 JSON Pointer of n, e.g. `/items/0/name`.
+func (n *Node) Pointer() string {
+	if n.Parent == nil {
+		return ""
+	}
+	return n.Parent.Pointer() + "/" + EscapePointer(n.Key)
+}
+
+// EscapePointer escapes a single JSON Pointer reference token.
+func EscapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,29 @@
+package parser
+
+import "testing"
+
+func TestSplitLast(t *testing.T) {
+	tests := []struct {
+		expr, base, last string
+	}{
+		{"", "", ""},
+		{"$", "$", ""},
+		{"$.", "$", "."},
+		{"$.items", "$", ".items"},
+		{"$.items[0].na", "$.items[0]", ".na"},
+		{"$.items[", "$.items", "["},
+		{"$.items[1", "$.items", "[1"},
+		{"items", "", "items"},
+		{"items.", "items", "."},
+		{`$["a.b"].c`, `$["a.b"]`, ".c"},
+		{`$["a.b`, "$", `["a.b`},
+		{`$["a]b"][0]`, `$["a]b"]`, "[0]"},
+		{`$["a\"].b`, "$", `["a\"].b`},
+	}
+	for _, tt := range tests {
+		base, last := SplitLast(tt.expr)
+		if base != tt.base || last != tt.last {
+			t.Errorf("SplitLast(%q) = %q, %q; want %q, %q", tt.expr, base, last, tt.base, tt.last)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Extent is the compact serialized size of a subtree in bytes and the
+// number of nodes it contains, including itself.
+type Extent struct {
+	Bytes int
+	Nodes int
+}
+
+// Measure computes the extent of every subtree of root in one pass.
+func Measure(root *Node) map[*Node]Extent {
+	out := map[*Node]Extent{}
+	measure(root, out)
+	return out
+}
+
+func measure(n *Node, out map[*Node]Extent) Extent {
+	ext := Extent{Nodes: 1}
+	switch n.Type {
+	case TypeObject, TypeArray:
+		ext.Bytes = 2
+		for i, child := range n.Children {
+			c := measure(child, out)
+			ext.Bytes += c.Bytes
+			ext.Nodes += c.Nodes
+			if i > 0 {
+				ext.Bytes++
+			}
+			if n.Type == TypeObject {
+				ext.Bytes += quotedLen(child.Key) + 1
+			}
+		}
+	case TypeString:
+		if s, ok := n.Value.(string); ok {
+			ext.Bytes = quotedLen(s)
+		} else {
+			ext.Bytes = len(n.StringValue())
+		}
+	default:
+		ext.Bytes = len(n.StringValue())
+	}
+	out[n] = ext
+	return ext
+}
+
+// quotedLen returns the length of s encoded as a JSON string the way
+// encoding/json writes it without HTML escaping.
+func quotedLen(s string) int {
+	size := 2
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '"' || r == '\\' || r == '\n' || r == '\r' || r == '\t' || r == '\b' || r == '\f':
+			size += 2
+		case r < 0x20 || r == '\u2028' || r == '\u2029' || (r == utf8.RuneError && width == 1):
+			size += 6
+		default:
+			size += width
+		}
+		i += width
+	}
+	return size
+}
+
+// FormatBytes renders a byte count with a binary unit, e.g. "12.3 KiB".
+func FormatBytes(n int) string {
+	if n < 1024 {
+		return strconv.Itoa(n) + " B"
+	}
+	value := float64(n)
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+	unit := ""
+	for _, u := range units {
+		value /= 1024
+		unit = u
+		if value < 1024 {
+			break
+		}
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + " " + unit
+}
@@ -0,0 +1,334 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+// OpError reports the JSON Patch operation that could not be applied.
+// Index is zero-based.
+type OpError struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *OpError) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("operation %d (%s %q): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// Apply applies patch to doc, treating an array as an RFC 6902 JSON Patch
+// and anything else as an RFC 7396 merge patch. Both arguments are decoded
+// JSON values; doc may be modified in place.
+func Apply(doc, patch any) (any, error) {
+	if _, ok := patch.([]any); ok {
+		return ApplyJSONPatch(doc, patch)
+	}
+	return ApplyMergePatch(doc, patch), nil
+}
+
+// ApplyJSONPatch applies the operations of an RFC 6902 patch in order and
+// stops at the first one that fails.
+func ApplyJSONPatch(doc, patch any) (any, error) {
+	ops, ok := patch.([]any)
+	if !ok {
+		return nil, errors.New("a JSON Patch must be an array of operations")
+	}
+	for i, raw := range ops {
+		op, ok := raw.(map[string]any)
+		if !ok {
+			return nil, &OpError{Index: i, Err: errors.New("operation must be an object")}
+		}
+		name, _ := op["op"].(string)
+		path, _ := op["path"].(string)
+		var err error
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, &OpError{Index: i, Op: name, Path: path, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc any, op map[string]any) (any, error) {
+	name, ok := op["op"].(string)
+	if !ok {
+		return nil, errors.New(`missing "op"`)
+	}
+	rawPath, ok := op["path"].(string)
+	if !ok {
+		return nil, errors.New(`missing "path"`)
+	}
+	path, err := parsePointer(rawPath)
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := op["value"]
+
+	switch name {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, errors.New(`missing "value"`)
+		}
+	case "move", "copy":
+		rawFrom, ok := op["from"].(string)
+		if !ok {
+			return nil, errors.New(`missing "from"`)
+		}
+		from, err := parsePointer(rawFrom)
+		if err != nil {
+			return nil, err
+		}
+		if name == "move" && len(from) < len(path) && isPrefix(from, path) {
+			return nil, fmt.Errorf("cannot move %q into its own child", rawFrom)
+		}
+		value, err = get(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if name == "move" {
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return add(doc, path, value)
+	}
+
+	switch name {
+	case "add":
+		return add(doc, path, value)
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		return update(doc, path, func(container any, token string) (any, error) {
+			if arr, ok := container.([]any); ok {
+				i, _ := arrayIndex(token, len(arr), false)
+				arr[i] = value
+				return arr, nil
+			}
+			container.(map[string]any)[token] = value
+			return container, nil
+		})
+	case "test":
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !parser.EqualValues(current, value) {
+			return nil, errors.New("test failed: value differs")
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[token] = value
+			return c, nil
+		case []any:
+			i, err := arrayIndex(token, len(c), true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		default:
+			return nil, errors.New("parent is not an object or array")
+		}
+	})
+}
+
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove the document root")
+	}
+	return update(doc, path, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			if _, ok := c[token]; !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			delete(c, token)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, errors.New("parent is not an object or array")
+		}
+	})
+}
+
+// update walks to the container holding the last token of path, replaces
+// it with the result of fn and rebuilds the chain of parents, since
+// inserting into or removing from a slice may reallocate it.
+func update(doc any, path []string, fn func(container any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch c := doc.(type) {
+	case map[string]any:
+		child, ok := c[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", path[0])
+		}
+		updated, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[path[0]] = updated
+		return c, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(c), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(c[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = updated
+		return c, nil
+	default:
+		return nil, fmt.Errorf("cannot descend into %q: not an object or array", path[0])
+	}
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch c := doc.(type) {
+		case map[string]any:
+			child, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			doc = child
+		case []any:
+			i, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, fmt.Errorf("cannot descend into %q: not an object or array", token)
+		}
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array reference token. "-" refers to the position
+// past the last element and is only accepted when allowEnd is set, as is
+// an index equal to the length.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > length || (i == length && !allowEnd) {
+		return 0, fmt.Errorf("index %d out of range (length %d)", i, length)
+	}
+	return i, nil
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q: must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func deepCopy(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = deepCopy(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// ApplyMergePatch applies an RFC 7396 merge patch: object members are
+// merged recursively, null deletes a member and any other value replaces
+// the target.
+func ApplyMergePatch(doc, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	target, ok := doc.(map[string]any)
+	if !ok {
+		target = map[string]any{}
+	}
+	for k, v := range members {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		target[k] = ApplyMergePatch(target[k], v)
+	}
+	return target
+}
@@ -0,0 +1,144 @@
+package patch
+
+import (
+	"fmt"
+
+	"github.com/simota/jv/internal/diff"
+	"github.com/simota/jv/internal/parser"
+)
+
+// JSONPatch builds an RFC 6902 patch that turns the old side of the aligned
+// tree into the new side. Arrays whose elements moved are replaced whole,
+// since index-based operations cannot express the reordering.
+func JSONPatch(root *diff.Pair) []any {
+	ops := []any{}
+	jsonPatch(root, &ops)
+	return ops
+}
+
+func jsonPatch(p *diff.Pair, ops *[]any) {
+	switch p.Kind {
+	case diff.Added:
+		*ops = append(*ops, operation("add", p.New.Pointer(), p.New.ToValue()))
+		return
+	case diff.Removed:
+		*ops = append(*ops, map[string]any{"op": "remove", "path": p.Old.Pointer()})
+		return
+	case diff.Changed:
+		*ops = append(*ops, operation("replace", p.Old.Pointer(), p.New.ToValue()))
+		return
+	}
+	if p.Changes() == 0 {
+		return
+	}
+	if p.Old.Type == parser.TypeArray && !inPlace(p) {
+		*ops = append(*ops, operation("replace", p.Old.Pointer(), p.New.ToValue()))
+		return
+	}
+
+	// Removals come last and from the highest index down so that earlier
+	// operations do not shift the indexes of later ones.
+	removed := []*diff.Pair{}
+	for _, child := range p.Children {
+		if child.Kind == diff.Removed {
+			removed = append(removed, child)
+			continue
+		}
+		jsonPatch(child, ops)
+	}
+	for i := len(removed) - 1; i >= 0; i-- {
+		jsonPatch(removed[i], ops)
+	}
+}
+
+// inPlace reports whether the elements of an aligned array keep their
+// indexes, with additions and removals only past the end of the shorter
+// side.
+func inPlace(p *diff.Pair) bool {
+	for _, child := range p.Children {
+		switch {
+		case child.Old != nil && child.New != nil:
+			if child.Old.Key != child.New.Key {
+				return false
+			}
+		case child.Old != nil:
+			if index(child.Old) < len(p.New.Children) {
+				return false
+			}
+		default:
+			if index(child.New) < len(p.Old.Children) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func index(node *parser.Node) int {
+	for i, child := range node.Parent.Children {
+		if child == node {
+			return i
+		}
+	}
+	return -1
+}
+
+func operation(op, path string, value any) map[string]any {
+	return map[string]any{"op": op, "path": path, "value": value}
+}
+
+// MergePatch builds an RFC 7396 merge patch that turns the old side of the
+// aligned tree into the new side. Merge patches use null to delete members,
+// so a member that becomes null cannot be expressed and is reported as an
+// error.
+func MergePatch(root *diff.Pair) (any, error) {
+	if !mergeable(root) {
+		return root.New.ToValue(), nil
+	}
+	return mergePatch(root)
+}
+
+func mergeable(p *diff.Pair) bool {
+	return p.Kind == "" && p.Old.Type == parser.TypeObject
+}
+
+func mergePatch(p *diff.Pair) (map[string]any, error) {
+	out := map[string]any{}
+	for _, child := range p.Children {
+		switch {
+		case child.Kind == diff.Removed:
+			out[child.Key] = nil
+		case child.Kind == "" && child.Changes() == 0:
+		case mergeable(child):
+			nested, err := mergePatch(child)
+			if err != nil {
+				return nil, err
+			}
+			out[child.Key] = nested
+		default:
+			if node := nullMember(child.New); node != nil {
+				return nil, fmt.Errorf("%s: null values cannot be expressed in a merge patch", node.Path())
+			}
+			out[child.Key] = child.New.ToValue()
+		}
+	}
+	return out, nil
+}
+
+// nullMember returns the first null value that applying node as a merge
+// patch would drop: node itself or a member of one of its nested objects.
+// Arrays are applied verbatim, so their elements are not inspected.
+func nullMember(node *parser.Node) *parser.Node {
+	if node.Type == parser.TypeNull {
+		return node
+	}
+	if node.Type != parser.TypeObject {
+		return nil
+	}
+	for _, child := range node.Children {
+		if found := nullMember(child); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package patch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/simota/jv/internal/diff"
+	"github.com/simota/jv/internal/parser"
+)
+
+func parse(t *testing.T, input string) *parser.Node {
+	t.Helper()
+	root, err := parser.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func value(t *testing.T, input string) any {
+	t.Helper()
+	return parse(t, input).ToValue()
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add replaces member", `{"a":1}`, `[{"op":"add","path":"/a","value":2}]`, `{"a":2}`},
+		{"add inserts", `[1,3]`, `[{"op":"add","path":"/1","value":2}]`, `[1,2,3]`},
+		{"add appends", `[1,2]`, `[{"op":"add","path":"/-","value":3}]`, `[1,2,3]`},
+		{"add at length", `[1]`, `[{"op":"add","path":"/1","value":2}]`, `[1,2]`},
+		{"add nested append", `{"a":{"b":[]}}`, `[{"op":"add","path":"/a/b/-","value":1}]`, `{"a":{"b":[1]}}`},
+		{"add root", `{"a":1}`, `[{"op":"add","path":"","value":[1]}]`, `[1]`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"remove element", `[1,2,3]`, `[{"op":"remove","path":"/1"}]`, `[1,3]`},
+		{"replace", `{"a":[1,2]}`, `[{"op":"replace","path":"/a/0","value":"x"}]`, `{"a":["x",2]}`},
+		{"replace root", `1`, `[{"op":"replace","path":"","value":2}]`, `2`},
+		{"move", `{"a":{"b":1},"c":{}}`, `[{"op":"move","from":"/a/b","path":"/c/d"}]`, `{"a":{},"c":{"d":1}}`},
+		{"move element", `[1,2,3]`, `[{"op":"move","from":"/0","path":"/-"}]`, `[2,3,1]`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"test", `{"a":[1,{"b":null}]}`, `[{"op":"test","path":"/a","value":[1.0,{"b":null}]}]`, `{"a":[1,{"b":null}]}`},
+		{"escaped slash", `{"a/b":1}`, `[{"op":"replace","path":"/a~1b","value":2}]`, `{"a/b":2}`},
+		{"escaped tilde", `{"m~n":1}`, `[{"op":"remove","path":"/m~0n"}]`, `{}`},
+		{"escape order", `{}`, `[{"op":"add","path":"/~01","value":1}]`, `{"~1":1}`},
+		{"in order", `{}`, `[{"op":"add","path":"/a","value":[]},{"op":"add","path":"/a/-","value":1},{"op":"test","path":"/a/0","value":1}]`, `{"a":[1]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch(value(t, tt.doc), value(t, tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !parser.EqualValues(got, value(t, tt.want)) {
+				t.Fatalf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchCopyIsDeep(t *testing.T) {
+	got, err := ApplyJSONPatch(value(t, `{"a":{"b":1}}`), value(t, `[
+		{"op":"copy","from":"/a","path":"/c"},
+		{"op":"replace","path":"/c/b","value":2}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parser.EqualValues(got, value(t, `{"a":{"b":1},"c":{"b":2}}`)) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		index int
+		op    string
+		path  string
+		err   string
+	}{
+		{"failing test", `{"a":1}`, `[{"op":"test","path":"/a","value":"1"}]`, 0, "test", "/a", "test failed"},
+		{"later operation", `{"a":1}`, `[{"op":"remove","path":"/a"},{"op":"remove","path":"/a"}]`, 1, "remove", "/a", `no member "a"`},
+		{"not an object", `{}`, `[1]`, 0, "", "", "operation must be an object"},
+		{"missing op", `{}`, `[{"path":"/a"}]`, 0, "", "/a", `missing "op"`},
+		{"missing path", `{}`, `[{"op":"add","value":1}]`, 0, "add", "", `missing "path"`},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, 0, "add", "/a", `missing "value"`},
+		{"missing from", `{}`, `[{"op":"copy","path":"/a"}]`, 0, "copy", "/a", `missing "from"`},
+		{"unknown op", `{}`, `[{"op":"frob","path":"/a"}]`, 0, "frob", "/a", "unknown operation"},
+		{"bad pointer", `{}`, `[{"op":"add","path":"a","value":1}]`, 0, "add", "a", "must start with /"},
+		{"missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, 0, "add", "/a/b", `no member "a"`},
+		{"index out of range", `[1]`, `[{"op":"add","path":"/2","value":1}]`, 0, "add", "/2", "out of range"},
+		{"leading zero", `[1,2]`, `[{"op":"replace","path":"/01","value":1}]`, 0, "replace", "/01", "invalid array index"},
+		{"remove end", `[1]`, `[{"op":"remove","path":"/-"}]`, 0, "remove", "/-", "invalid array index"},
+		{"replace missing", `{}`, `[{"op":"replace","path":"/a","value":1}]`, 0, "replace", "/a", `no member "a"`},
+		{"remove root", `{}`, `[{"op":"remove","path":""}]`, 0, "remove", "", "cannot remove the document root"},
+		{"move into child", `{"a":{}}`, `[{"op":"move","from":"/a","path":"/a/b"}]`, 0, "move", "/a/b", "own child"},
+		{"scalar parent", `{"a":1}`, `[{"op":"add","path":"/a/b","value":1}]`, 0, "add", "/a/b", "not an object or array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyJSONPatch(value(t, tt.doc), value(t, tt.patch))
+			var opErr *OpError
+			if !errors.As(err, &opErr) {
+				t.Fatalf("err = %v, want *OpError", err)
+			}
+			if opErr.Index != tt.index || opErr.Op != tt.op || opErr.Path != tt.path {
+				t.Errorf("got operation %d %q %q, want %d %q %q", opErr.Index, opErr.Op, opErr.Path, tt.index, tt.op, tt.path)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchNotArray(t *testing.T) {
+	if _, err := ApplyJSONPatch(value(t, `{}`), value(t, `{"op":"add"}`)); err == nil {
+		t.Fatal("applied an object as a JSON Patch")
+	}
+}
+
+func TestJSONPatchRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		key  string
+	}{
+		{"members", `{"a":1,"b":2,"c":{"d":3}}`, `{"a":1,"c":{"d":4,"e":5},"f":null}`, ""},
+		{"escaped keys", `{"a/b":1,"m~n":2}`, `{"a/b":3,"x~1":4}`, ""},
+		{"append", `[1,2]`, `[1,2,3,4]`, ""},
+		{"truncate", `[1,2,3,4]`, `[1]`, ""},
+		{"nested arrays", `{"a":[[1],[2,3]]}`, `{"a":[[1,0],[2]]}`, ""},
+		{"type change", `{"a":[1]}`, `{"a":{"0":1}}`, ""},
+		{"root", `[1]`, `"x"`, ""},
+		{"reordered", `[{"id":1,"v":"x"},{"id":2,"v":"y"}]`, `[{"id":2,"v":"z"},{"id":3},{"id":1,"v":"x"}]`, "id"},
+		{"keyed removal", `[{"id":1},{"id":2},{"id":3}]`, `[{"id":1},{"id":3}]`, "id"},
+		{"unchanged", `{"a":[1,{"b":2}]}`, `{"a":[1,{"b":2}]}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := JSONPatch(diff.Align(parse(t, tt.a), parse(t, tt.b), diff.Options{ArrayKey: tt.key}))
+			got, err := ApplyJSONPatch(value(t, tt.a), ops)
+			if err != nil {
+				t.Fatalf("%v\npatch: %v", err, ops)
+			}
+			if !parser.EqualValues(got, value(t, tt.b)) {
+				t.Fatalf("got %v, want %s\npatch: %v", got, tt.b, ops)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"deletion", `{"a":1,"b":2}`, `{"a":1}`, `{"b":null}`},
+		{"nested deletion", `{"a":{"b":1,"c":2}}`, `{"a":{"c":2}}`, `{"a":{"b":null}}`},
+		{"replace and add", `{"a":1,"b":{"c":1}}`, `{"a":"x","b":{"c":1,"d":[1]}}`, `{"a":"x","b":{"d":[1]}}`},
+		{"array replaced whole", `{"a":[1,2]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"not an object", `[1]`, `{"a":1}`, `{"a":1}`},
+		{"unchanged", `{"a":1}`, `{"a":1}`, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := MergePatch(diff.Align(parse(t, tt.a), parse(t, tt.b), diff.Options{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !parser.EqualValues(mp, value(t, tt.want)) {
+				t.Fatalf("got %v, want %s", mp, tt.want)
+			}
+			if got := ApplyMergePatch(value(t, tt.a), mp); !parser.EqualValues(got, value(t, tt.b)) {
+				t.Fatalf("applied: got %v, want %s", got, tt.b)
+			}
+		})
+	}
+}
+
+func TestMergePatchNullValue(t *testing.T) {
+	for _, b := range []string{`{"a":null}`, `{"a":{"b":null}}`} {
+		_, err := MergePatch(diff.Align(parse(t, `{"a":1}`), parse(t, b), diff.Options{}))
+		if err == nil || !strings.Contains(err.Error(), "null values cannot be expressed") {
+			t.Errorf("%s: err = %v", b, err)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		// Examples from RFC 7396, appendix A.
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		if got := ApplyMergePatch(value(t, tt.doc), value(t, tt.patch)); !parser.EqualValues(got, value(t, tt.want)) {
+			t.Errorf("%s + %s: got %v, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,532 @@
+package pipe
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+type Language string
+
+const (
+	LangGo         Language = "go"
+	LangTypeScript Language = "typescript"
+	LangPython     Language = "python-dataclass"
+)
+
+// CodeFormatter generates type declarations for the inferred shape of the
+// input. Nested objects become named types derived from their keys, and
+// array element types are merged from every element.
+type CodeFormatter struct {
+	lang     Language
+	rootName string
+}
+
+func NewCodeFormatter(lang Language, rootName string) *CodeFormatter {
+	if rootName == "" {
+		rootName = "Root"
+	}
+	return &CodeFormatter{lang: lang, rootName: rootName}
+}
+
+func (f *CodeFormatter) Format(w io.Writer, root *parser.Node) error {
+	return f.FormatSamples(w, []*parser.Node{root})
+}
+
+// FormatSamples writes the declarations in one piece: Go source is only
+// known to be well-formed once every type has been generated.
+func (f *CodeFormatter) FormatSamples(w io.Writer, samples []*parser.Node) error {
+	shape := InferShape(samples...)
+	var code string
+	var err error
+	switch f.lang {
+	case LangTypeScript:
+		code = newTSGen().generate(shape, f.rootName)
+	case LangPython:
+		code = newPyGen().generate(shape, f.rootName)
+	default:
+		code, err = newGoGen().generate(shape, f.rootName)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, code)
+	return err
+}
+
+// namedType is an object shape that is emitted as a declaration.
+type namedType struct {
+	name  string
+	shape *Shape
+}
+
+// typeRegistry assigns unique names to object shapes in discovery order.
+type typeRegistry struct {
+	types []namedType
+	used  map[string]int
+	names map[*Shape]string
+}
+
+// newTypeRegistry returns a registry in which the reserved names, those
+// the generated code refers to, get a numeric suffix like any other
+// repeated name.
+func newTypeRegistry(reserved ...string) *typeRegistry {
+	r := &typeRegistry{used: map[string]int{}, names: map[*Shape]string{}}
+	for _, name := range reserved {
+		r.used[name] = 1
+	}
+	return r
+}
+
+func (r *typeRegistry) define(shape *Shape, hint string) (string, bool) {
+	if name, ok := r.names[shape]; ok {
+		return name, false
+	}
+	name := typeName(hint)
+	r.used[name]++
+	if n := r.used[name]; n > 1 {
+		name += strconv.Itoa(n)
+	}
+	r.names[shape] = name
+	r.types = append(r.types, namedType{name: name, shape: shape})
+	return name, true
+}
+
+var commonInitialisms = map[string]string{
+	"id": "ID", "url": "URL", "uri": "URI", "api": "API", "http": "HTTP", "https": "HTTPS",
+	"json": "JSON", "uuid": "UUID", "ip": "IP", "html": "HTML", "sql": "SQL", "xml": "XML",
+}
+
+var (
+	wordSplitRe     = regexp.MustCompile(`[A-Z]+[a-z0-9]*|[a-z0-9]+|[A-Z]+`)
+	wordSeparatorRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+func splitWords(key string) []string {
+	words := []string{}
+	for _, part := range wordSeparatorRe.Split(key, -1) {
+		words = append(words, wordSplitRe.FindAllString(part, -1)...)
+	}
+	return words
+}
+
+// typeName converts a key such as "billing_address" into "BillingAddress".
+func typeName(key string) string {
+	var b strings.Builder
+	for _, word := range splitWords(key) {
+		if upper, ok := commonInitialisms[strings.ToLower(word)]; ok {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+	}
+	name := b.String()
+	if name == "" {
+		return "Type"
+	}
+	if unicode.IsDigit(rune(name[0])) {
+		name = "T" + name
+	}
+	return name
+}
+
+// singular derives an element name from an array key: "projects" becomes
+// "project" and "categories" becomes "category".
+func singular(key string) string {
+	lower := strings.ToLower(key)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(key) > 3:
+		return key[:len(key)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return key[:len(key)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") && len(key) > 1:
+		return key[:len(key)-1]
+	}
+	return key + "Item"
+}
+
+// shapeKind returns the single non-null type of shape, or "" for unions
+// and shapes with no samples.
+func shapeKind(shape *Shape) parser.NodeType {
+	types := shape.NonNullTypes()
+	if len(types) != 1 {
+		return ""
+	}
+	return types[0]
+}
+
+type goGen struct {
+	reg *typeRegistry
+}
+
+func newGoGen() *goGen {
+	return &goGen{reg: newTypeRegistry()}
+}
+
+func (g *goGen) generate(shape *Shape, rootName string) (string, error) {
+	var buf bytes.Buffer
+	if shapeKind(shape) == parser.TypeObject && len(shape.Fields) > 0 {
+		g.reg.define(shape, rootName)
+	} else {
+		fmt.Fprintf(&buf, "type %s %s\n\n", rootName, g.expr(shape, rootName, false))
+	}
+	for i := 0; i < len(g.reg.types); i++ {
+		t := g.reg.types[i]
+		fmt.Fprintf(&buf, "type %s struct {\n", t.name)
+		used := map[string]bool{}
+		for _, field := range t.shape.Fields {
+			optional := field.Optional(t.shape)
+			typ := g.expr(field.Shape, field.Name, optional || field.Shape.Nullable())
+			tag := field.Name
+			if optional {
+				tag += ",omitempty"
+			} else if tag == "-" {
+				// A bare "-" tells encoding/json to skip the field.
+				tag += ","
+			}
+			name := uniqueField(used, goFieldName(field.Name))
+			fmt.Fprintf(&buf, "\t%s %s %s\n", name, typ, goTag("json:"+strconv.Quote(tag)))
+		}
+		buf.WriteString("}\n\n")
+	}
+	src := bytes.TrimRight(buf.Bytes(), "\n")
+	formatted, err := format.Source(append(src, '\n'))
+	if err != nil {
+		return "", fmt.Errorf("generating Go: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// goTag returns a struct tag literal, raw unless the tag holds a backtick.
+func goTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// expr returns the Go type for shape; pointer wraps scalars and structs
+// that may be absent or null.
+func (g *goGen) expr(shape *Shape, hint string, pointer bool) string {
+	typ := ""
+	switch shapeKind(shape) {
+	case parser.TypeObject:
+		if len(shape.Fields) == 0 {
+			return "map[string]any"
+		}
+		typ, _ = g.reg.define(shape, hint)
+	case parser.TypeArray:
+		if shape.Items == nil || len(shape.Items.Types) == 0 || shape.IsTuple() {
+			return "[]any"
+		}
+		return "[]" + g.expr(shape.Items, singular(hint), shape.Items.Nullable())
+	case parser.TypeString:
+		typ = "string"
+	case parser.TypeNumber:
+		typ = "float64"
+		if shape.IntegerOnly() {
+			typ = "int64"
+		}
+	case parser.TypeBoolean:
+		typ = "bool"
+	default:
+		return "any"
+	}
+	if pointer {
+		return "*" + typ
+	}
+	return typ
+}
+
+// uniqueField returns name, or name with a numeric suffix when another
+// field of the same type already took it, as user_id and userId would.
+func uniqueField(used map[string]bool, name string) string {
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = name + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
+
+func goFieldName(key string) string {
+	name := typeName(key)
+	if name == "Type" && !strings.EqualFold(key, "type") {
+		return "Field"
+	}
+	return name
+}
+
+type tsGen struct {
+	reg *typeRegistry
+}
+
+// tsReserved are the global types that the generated code uses or that an
+// interface of the same name would shadow.
+var tsReserved = []string{
+	"Array", "Boolean", "Date", "Error", "Function", "JSON", "Map", "Number", "Object",
+	"Omit", "Partial", "Pick", "Promise", "Readonly", "Record", "Required", "Set", "String", "Symbol",
+}
+
+func newTSGen() *tsGen {
+	return &tsGen{reg: newTypeRegistry(tsReserved...)}
+}
+
+var tsIdentRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func (g *tsGen) generate(shape *Shape, rootName string) string {
+	var buf bytes.Buffer
+	if shapeKind(shape) == parser.TypeObject && len(shape.Fields) > 0 {
+		g.reg.define(shape, rootName)
+	} else {
+		fmt.Fprintf(&buf, "export type %s = %s;\n\n", rootName, g.expr(shape, rootName))
+	}
+	for i := 0; i < len(g.reg.types); i++ {
+		t := g.reg.types[i]
+		fmt.Fprintf(&buf, "export interface %s {\n", t.name)
+		for _, field := range t.shape.Fields {
+			name := field.Name
+			if !tsIdentRe.MatchString(name) {
+				name = strconv.Quote(name)
+			}
+			if field.Optional(t.shape) {
+				name += "?"
+			}
+			fmt.Fprintf(&buf, "  %s: %s;\n", name, g.expr(field.Shape, field.Name))
+		}
+		buf.WriteString("}\n\n")
+	}
+	return strings.TrimRight(buf.String(), "\n") + "\n"
+}
+
+func (g *tsGen) expr(shape *Shape, hint string) string {
+	parts := []string{}
+	for _, t := range shape.Types {
+		switch t {
+		case parser.TypeObject:
+			if len(shape.Fields) == 0 {
+				parts = append(parts, "Record<string, unknown>")
+				continue
+			}
+			name, _ := g.reg.define(shape, hint)
+			parts = append(parts, name)
+		case parser.TypeArray:
+			parts = append(parts, g.arrayExpr(shape, hint))
+		case parser.TypeString, parser.TypeNumber, parser.TypeBoolean, parser.TypeNull:
+			parts = append(parts, string(t))
+		}
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	// Keep null last so optional-looking unions read naturally.
+	if shape.Nullable() {
+		for i, part := range parts {
+			if part == "null" {
+				parts = append(append(parts[:i:i], parts[i+1:]...), "null")
+				break
+			}
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (g *tsGen) arrayExpr(shape *Shape, hint string) string {
+	if shape.Items == nil || len(shape.Items.Types) == 0 {
+		return "unknown[]"
+	}
+	if shape.IsTuple() {
+		items := make([]string, 0, len(shape.Tuple))
+		for _, item := range shape.Tuple {
+			items = append(items, g.expr(item, singular(hint)))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	item := g.expr(shape.Items, singular(hint))
+	if strings.Contains(item, " ") {
+		return "(" + item + ")[]"
+	}
+	return item + "[]"
+}
+
+type pyGen struct {
+	reg *typeRegistry
+}
+
+// pyReserved are the names the generated module imports, and the keywords
+// that a type name could turn into.
+var pyReserved = []string{"Any", "Dict", "List", "Optional", "Tuple", "Union", "False", "None", "True"}
+
+func newPyGen() *pyGen {
+	return &pyGen{reg: newTypeRegistry(pyReserved...)}
+}
+
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+var pyIdentRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (g *pyGen) generate(shape *Shape, rootName string) string {
+	alias := ""
+	if shapeKind(shape) == parser.TypeObject && len(shape.Fields) > 0 {
+		g.reg.define(shape, rootName)
+	} else {
+		alias = fmt.Sprintf("%s = %s\n", rootName, g.expr(shape, rootName))
+	}
+	// Resolve every nested type before printing so the classes can be
+	// emitted dependencies first.
+	for i := 0; i < len(g.reg.types); i++ {
+		for _, field := range g.reg.types[i].shape.Fields {
+			g.expr(field.Shape, field.Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("from __future__ import annotations\n\n")
+	buf.WriteString("from dataclasses import dataclass\n")
+	buf.WriteString("from typing import Any, Dict, List, Optional, Tuple, Union\n")
+	for i := len(g.reg.types) - 1; i >= 0; i-- {
+		t := g.reg.types[i]
+		fmt.Fprintf(&buf, "\n\n@dataclass\nclass %s:\n", t.name)
+		required := []string{}
+		optional := []string{}
+		names := pyFieldNames(t.shape.Fields)
+		for _, field := range t.shape.Fields {
+			name := names[field]
+			renamed := name != field.Name
+			typ := g.expr(field.Shape, field.Name)
+			line := "    " + name + ": "
+			if field.Optional(t.shape) {
+				if !strings.HasPrefix(typ, "Optional[") {
+					typ = "Optional[" + typ + "]"
+				}
+				line += typ + " = None"
+			} else {
+				line += typ
+			}
+			if renamed {
+				line += "  # json: " + strconv.Quote(field.Name)
+			}
+			if field.Optional(t.shape) {
+				optional = append(optional, line)
+			} else {
+				required = append(required, line)
+			}
+		}
+		for _, line := range append(required, optional...) {
+			buf.WriteString(line + "\n")
+		}
+	}
+	if alias != "" {
+		buf.WriteString("\n\n" + alias)
+	}
+	return buf.String()
+}
+
+func (g *pyGen) expr(shape *Shape, hint string) string {
+	parts := []string{}
+	for _, t := range shape.NonNullTypes() {
+		switch t {
+		case parser.TypeObject:
+			if len(shape.Fields) == 0 {
+				parts = append(parts, "Dict[str, Any]")
+				continue
+			}
+			name, _ := g.reg.define(shape, hint)
+			parts = append(parts, name)
+		case parser.TypeArray:
+			parts = append(parts, g.arrayExpr(shape, hint))
+		case parser.TypeString:
+			parts = append(parts, "str")
+		case parser.TypeNumber:
+			if shape.IntegerOnly() {
+				parts = append(parts, "int")
+			} else {
+				parts = append(parts, "float")
+			}
+		case parser.TypeBoolean:
+			parts = append(parts, "bool")
+		case parser.TypeNull:
+			parts = append(parts, "None")
+		}
+	}
+	typ := "Any"
+	switch len(parts) {
+	case 0:
+	case 1:
+		typ = parts[0]
+	default:
+		typ = "Union[" + strings.Join(parts, ", ") + "]"
+	}
+	if shape.Nullable() {
+		return "Optional[" + typ + "]"
+	}
+	return typ
+}
+
+func (g *pyGen) arrayExpr(shape *Shape, hint string) string {
+	if shape.Items == nil || len(shape.Items.Types) == 0 {
+		return "List[Any]"
+	}
+	if shape.IsTuple() {
+		items := make([]string, 0, len(shape.Tuple))
+		for _, item := range shape.Tuple {
+			items = append(items, g.expr(item, singular(hint)))
+		}
+		return "Tuple[" + strings.Join(items, ", ") + "]"
+	}
+	return "List[" + g.expr(shape.Items, singular(hint)) + "]"
+}
+
+// pyFieldNames assigns each field a distinct attribute name. Keys that are
+// valid names already keep them; the others are converted and numbered
+// when they collide.
+func pyFieldNames(fields []*Field) map[*Field]string {
+	names := map[*Field]string{}
+	used := map[string]bool{}
+	for _, field := range fields {
+		if name, renamed := pyFieldName(field.Name); !renamed {
+			names[field] = name
+			used[name] = true
+		}
+	}
+	for _, field := range fields {
+		if _, ok := names[field]; !ok {
+			name, _ := pyFieldName(field.Name)
+			names[field] = uniqueField(used, name)
+		}
+	}
+	return names
+}
+
+// pyFieldName returns a valid attribute name for key and whether it had
+// to be changed.
+func pyFieldName(key string) (string, bool) {
+	if pyIdentRe.MatchString(key) && !pyKeywords[key] {
+		return key, false
+	}
+	words := splitWords(key)
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+	name := strings.Join(words, "_")
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "field_" + name
+	}
+	if pyKeywords[name] {
+		name += "_"
+	}
+	return name, true
+}
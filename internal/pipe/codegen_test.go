@@ -0,0 +1,105 @@
+package pipe
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	jvparser "github.com/simota/jv/internal/parser"
+)
+
+func generate(t *testing.T, lang Language, input string) string {
+	t.Helper()
+	root, err := jvparser.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := NewCodeFormatter(lang, "Root").Format(&out, root); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+// checkGo parses and type-checks generated Go code.
+func checkGo(t *testing.T, code string) (string, *ast.File) {
+	t.Helper()
+	src := "package p\n\n" + code
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "gen.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	if _, err := (&types.Config{}).Check("p", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated code does not type-check: %v\n%s", err, src)
+	}
+	return src, file
+}
+
+func TestGoCollidingFieldNames(t *testing.T) {
+	src, _ := checkGo(t, generate(t, LangGo, `{"user_id":1,"userId":2,"$":"a","-":"b","type":"c"}`))
+	if !strings.Contains(src, "`json:\"-,\"`") {
+		t.Errorf("key - must be tagged json:\"-,\":\n%s", src)
+	}
+}
+
+func TestPythonCollidingFieldNames(t *testing.T) {
+	src := generate(t, LangPython, `{"user-id":1,"user_id":2,"class":3,"class_":4}`)
+	seen := map[string]bool{}
+	for _, line := range strings.Split(src, "\n") {
+		if !strings.HasPrefix(line, "    ") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+		if seen[name] {
+			t.Errorf("attribute %s declared twice:\n%s", name, src)
+		}
+		seen[name] = true
+	}
+}
+
+func TestGoBacktickKeys(t *testing.T) {
+	_, file := checkGo(t, generate(t, LangGo, "{\"x`y\":1,\"a\":{\"b`\":2}}"))
+	tags := []string{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok && field.Tag != nil {
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tags = append(tags, reflect.StructTag(tag).Get("json"))
+		}
+		return true
+	})
+	want := []string{"a", "x`y", "b`"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags %q, want %q", tags, want)
+	}
+}
+
+func TestReservedTypeNames(t *testing.T) {
+	py := generate(t, LangPython, `{"list":{"a":1},"any":{"b":[1,"x"]},"none":{"c":1}}`)
+	for _, want := range []string{"class List2:", "class Any2:", "class None2:", "b: List[Union[int, str]]", "list: List2"} {
+		if !strings.Contains(py, want) {
+			t.Errorf("Python lacks %q:\n%s", want, py)
+		}
+	}
+	for _, reserved := range []string{"class List:", "class Any:", "class None:"} {
+		if strings.Contains(py, reserved) {
+			t.Errorf("Python declares %q:\n%s", reserved, py)
+		}
+	}
+
+	ts := generate(t, LangTypeScript, `{"record":{"a":1},"m":{}}`)
+	for _, want := range []string{"interface Record2 {", "record: Record2;", "m: Record<string, unknown>;"} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("TypeScript lacks %q:\n%s", want, ts)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package pipe
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+// ArrayMode controls how nested arrays are flattened into CSV cells.
+type ArrayMode string
+
+const (
+	// ArrayJSON stores the array as a JSON string in a single cell.
+	ArrayJSON ArrayMode = "json"
+	// ArrayIndex expands every element into its own `path.N` column.
+	ArrayIndex ArrayMode = "index"
+	// ArrayJoin joins primitive elements with ArrayJoinSep.
+	ArrayJoin ArrayMode = "join"
+)
+
+const ArrayJoinSep = ";"
+
+func ParseArrayMode(s string) (ArrayMode, error) {
+	switch mode := ArrayMode(s); mode {
+	case ArrayJSON, ArrayIndex, ArrayJoin:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid array mode: %s (json/index/join)", s)
+	}
+}
+
+type CSVFormatter struct {
+	comma  rune
+	arrays ArrayMode
+}
+
+func NewCSVFormatter(comma rune, arrays ArrayMode) *CSVFormatter {
+	return &CSVFormatter{comma: comma, arrays: arrays}
+}
+
+func (f *CSVFormatter) Check(root *parser.Node) error {
+	if root.Type != parser.TypeArray && root.Type != parser.TypeObject {
+		return errors.New("csv output requires an array of objects, got " + string(root.Type))
+	}
+	return nil
+}
+
+func (f *CSVFormatter) Format(out io.Writer, root *parser.Node) error {
+	rows := []*parser.Node{root}
+	if root.Type == parser.TypeArray {
+		rows = root.Children
+	}
+
+	headers := []string{}
+	seen := map[string]bool{}
+	flat := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		cells := map[string]string{}
+		order := []string{}
+		f.flatten(row, "", cells, &order)
+		for _, key := range order {
+			if !seen[key] {
+				seen[key] = true
+				headers = append(headers, key)
+			}
+		}
+		flat = append(flat, cells)
+	}
+
+	w := csv.NewWriter(out)
+	w.Comma = f.comma
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+	record := make([]string, len(headers))
+	for _, cells := range flat {
+		for i, key := range headers {
+			record[i] = cells[key]
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func (f *CSVFormatter) flatten(node *parser.Node, prefix string, cells map[string]string, order *[]string) {
+	set := func(key, value string) {
+		if key == "" {
+			key = tableValueColumn
+		}
+		if _, ok := cells[key]; !ok {
+			*order = append(*order, key)
+		}
+		cells[key] = value
+	}
+
+	switch node.Type {
+	case parser.TypeObject:
+		if len(node.Children) == 0 && prefix != "" {
+			set(prefix, "{}")
+			return
+		}
+		for _, child := range node.Children {
+			f.flatten(child, joinCSVKey(prefix, child.Key), cells, order)
+		}
+	case parser.TypeArray:
+		switch f.arrays {
+		case ArrayIndex:
+			if len(node.Children) == 0 {
+				set(prefix, "[]")
+				return
+			}
+			for _, child := range node.Children {
+				f.flatten(child, joinCSVKey(prefix, child.Key), cells, order)
+			}
+		case ArrayJoin:
+			if isPrimitiveArray(node) {
+				parts := make([]string, 0, len(node.Children))
+				for _, child := range node.Children {
+					parts = append(parts, csvScalar(child))
+				}
+				set(prefix, strings.Join(parts, ArrayJoinSep))
+				return
+			}
+			set(prefix, jsonText(node))
+		default:
+			set(prefix, jsonText(node))
+		}
+	default:
+		set(prefix, csvScalar(node))
+	}
+}
+
+func joinCSVKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func isPrimitiveArray(node *parser.Node) bool {
+	for _, child := range node.Children {
+		if child.Type == parser.TypeObject || child.Type == parser.TypeArray {
+			return false
+		}
+	}
+	return true
+}
+
+func csvScalar(node *parser.Node) string {
+	switch node.Type {
+	case parser.TypeString:
+		if v, ok := node.Value.(string); ok {
+			return v
+		}
+		return fmt.Sprintf("%v", node.Value)
+	case parser.TypeNull:
+		return ""
+	case parser.TypeBoolean, parser.TypeNumber:
+		return node.StringValue()
+	default:
+		return jsonText(node)
+	}
+}
+
+func jsonText(node *parser.Node) string {
+	data, err := json.Marshal(node.ToValue())
+	if err != nil {
+		return strconv.Quote(node.StringValue())
+	}
+	return string(data)
+}
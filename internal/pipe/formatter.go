@@ -1,30 +1,119 @@
 package pipe
 
-import "github.com/simota/jv/internal/parser"
+import (
+	"bufio"
+	"io"
 
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/theme"
+)
+
+// Formatter streams a document to w. Output is buffered and flushed before
+// Format returns; the first write error stops formatting and is returned.
 type Formatter interface {
-	Format(root *parser.Node) string
+	Format(w io.Writer, root *parser.Node) error
+}
+
+// Checker is implemented by formatters that cannot represent every
+// document. Check is called before Format.
+type Checker interface {
+	Check(root *parser.Node) error
+}
+
+// SampleFormatter is implemented by formatters that infer their output from
+// several sample documents, such as the records of an NDJSON file.
+type SampleFormatter interface {
+	FormatSamples(w io.Writer, samples []*parser.Node) error
+}
+
+// writer buffers formatter output and keeps the first write error, after
+// which further writes are dropped. Formatters check err while walking a
+// document so that they stop early once the reader has gone away, as with
+// `jv huge.json | head`.
+type writer struct {
+	buf *bufio.Writer
+	err error
+	// n counts the bytes written so far.
+	n int
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{buf: bufio.NewWriter(w)}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.buf.Write(p)
+	w.n += n
+	w.err = err
+	return n, err
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.buf.WriteString(s)
+	w.n += n
+	w.err = err
+	return n, err
 }
 
+func (w *writer) WriteByte(c byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.err = w.buf.WriteByte(c); w.err == nil {
+		w.n++
+	}
+	return w.err
+}
+
+// Flush writes any buffered output and returns the first error seen.
+func (w *writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.buf.Flush()
+	return w.err
+}
+
+// Colorizer paints tokens with the colors of a theme. The zero value
+// writes plain text; with Enabled set and no Theme it uses theme.Dark.
 type Colorizer struct {
 	Enabled bool
+	Theme   *theme.Theme
+	// Profile is the color depth of the terminal; the zero value uses
+	// 16-color codes.
+	Profile theme.Profile
+}
+
+func (c Colorizer) theme() *theme.Theme {
+	if c.Theme == nil {
+		return &theme.Dark
+	}
+	return c.Theme
 }
 
-func (c Colorizer) wrap(code int, s string) string {
+func (c Colorizer) wrap(style theme.Style, s string) string {
 	if !c.Enabled {
 		return s
 	}
-	return "\x1b[" + itoa(code) + "m" + s + "\x1b[0m"
+	return "\x1b[" + style.SGR(c.Profile) + "m" + s + "\x1b[0m"
 }
 
-func (c Colorizer) Key(s string) string    { return c.wrap(36, s) }
-func (c Colorizer) String(s string) string { return c.wrap(32, s) }
-func (c Colorizer) Number(s string) string { return c.wrap(33, s) }
-func (c Colorizer) Boolean(s string) string {
-	return c.wrap(35, s)
-}
-func (c Colorizer) Null(s string) string     { return c.wrap(90, s) }
-func (c Colorizer) TypeHint(s string) string { return c.wrap(90, s) }
+func (c Colorizer) Key(s string) string      { return c.wrap(c.theme().Key, s) }
+func (c Colorizer) String(s string) string   { return c.wrap(c.theme().String, s) }
+func (c Colorizer) Number(s string) string   { return c.wrap(c.theme().Number, s) }
+func (c Colorizer) Boolean(s string) string  { return c.wrap(c.theme().Boolean, s) }
+func (c Colorizer) Null(s string) string     { return c.wrap(c.theme().Null, s) }
+func (c Colorizer) TypeHint(s string) string { return c.wrap(c.theme().TypeHint, s) }
+func (c Colorizer) Added(s string) string    { return c.wrap(c.theme().Added, s) }
+func (c Colorizer) Removed(s string) string  { return c.wrap(c.theme().Removed, s) }
+func (c Colorizer) Changed(s string) string  { return c.wrap(c.theme().Changed, s) }
+func (c Colorizer) Header(s string) string   { return c.wrap(c.theme().Header, s) }
 
 func itoa(v int) string {
 	if v == 0 {
@@ -0,0 +1,296 @@
+package pipe
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+const (
+	// maxTupleLen is the longest heterogeneous array rendered as a tuple.
+	maxTupleLen = 8
+	// minTupleSamples is the number of same-length arrays needed before
+	// their positions are trusted to be fixed.
+	minTupleSamples = 2
+	// maxTrackedStrings bounds the distinct string values kept per shape.
+	maxTrackedStrings = 32
+)
+
+// Shape is the merged structure of one or more sample values.
+type Shape struct {
+	// Types lists the distinct types seen, in first-seen order.
+	Types []parser.NodeType
+	// Count is the number of samples merged into the shape.
+	Count int
+	// Objects is the number of object samples; a field is optional when
+	// it is present in fewer objects than this.
+	Objects int
+	Fields  []*Field
+	// Items is the merged shape of every element of every array sample.
+	Items *Shape
+	// Tuple holds per-position element shapes while every array sample
+	// has the same length.
+	Tuple []*Shape
+
+	// Numbers and Integers count number samples and those without a
+	// fractional part; Min and Max are valid when Numbers > 0. They keep
+	// the literals of the samples, so large integers are not rounded.
+	Numbers  int
+	Integers int
+	Min      json.Number
+	Max      json.Number
+	min, max *big.Rat
+
+	// Strings counts string samples. Values holds the distinct values
+	// seen, in first-seen order, unless there were more than
+	// maxTrackedStrings of them.
+	Strings     int
+	Values      []string
+	valueCount  map[string]int
+	manyValues  bool
+	format      string
+	formatMixed bool
+
+	tupleOK   bool
+	arraySeen bool
+	arrays    int
+	fieldIdx  map[string]*Field
+}
+
+type Field struct {
+	Name    string
+	Shape   *Shape
+	Present int
+}
+
+// InferShape merges samples into a single Shape.
+func InferShape(samples ...*parser.Node) *Shape {
+	s := &Shape{}
+	for _, sample := range samples {
+		s.Merge(sample)
+	}
+	return s
+}
+
+func (s *Shape) Merge(node *parser.Node) {
+	s.Count++
+	s.addType(node.Type)
+	switch node.Type {
+	case parser.TypeObject:
+		s.Objects++
+		if s.fieldIdx == nil {
+			s.fieldIdx = map[string]*Field{}
+		}
+		for _, child := range node.Children {
+			field, ok := s.fieldIdx[child.Key]
+			if !ok {
+				field = &Field{Name: child.Key, Shape: &Shape{}}
+				s.fieldIdx[child.Key] = field
+				s.Fields = append(s.Fields, field)
+			}
+			field.Present++
+			field.Shape.Merge(child)
+		}
+	case parser.TypeArray:
+		if s.Items == nil {
+			s.Items = &Shape{}
+		}
+		for _, child := range node.Children {
+			s.Items.Merge(child)
+		}
+		s.mergeTuple(node)
+	case parser.TypeNumber:
+		s.mergeNumber(node.StringValue())
+	case parser.TypeString:
+		v, _ := node.Value.(string)
+		s.mergeString(v)
+	}
+}
+
+func (s *Shape) mergeNumber(text string) {
+	v, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return
+	}
+	if s.Numbers == 0 || v.Cmp(s.min) < 0 {
+		s.Min, s.min = json.Number(text), v
+	}
+	if s.Numbers == 0 || v.Cmp(s.max) > 0 {
+		s.Max, s.max = json.Number(text), v
+	}
+	s.Numbers++
+	if !strings.ContainsAny(text, ".eE") {
+		s.Integers++
+	}
+}
+
+func (s *Shape) mergeString(v string) {
+	s.Strings++
+	if !s.manyValues {
+		if s.valueCount == nil {
+			s.valueCount = map[string]int{}
+		}
+		if _, ok := s.valueCount[v]; !ok {
+			s.Values = append(s.Values, v)
+		}
+		s.valueCount[v]++
+		if len(s.Values) > maxTrackedStrings {
+			s.manyValues = true
+			s.Values = nil
+			s.valueCount = nil
+		}
+	}
+	if s.formatMixed {
+		return
+	}
+	format := detectFormat(v)
+	if s.Strings == 1 {
+		s.format = format
+	} else if format != s.format {
+		s.format = ""
+		s.formatMixed = true
+	}
+}
+
+// IntegerOnly reports whether every number sample was an integer.
+func (s *Shape) IntegerOnly() bool {
+	return s.Numbers > 0 && s.Integers == s.Numbers
+}
+
+// Format returns the string format shared by every string sample, such
+// as "date-time" or "email", or "" when there is none.
+func (s *Shape) Format() string {
+	return s.format
+}
+
+// Enum returns the distinct string values when there are at most max of
+// them and at least one repeats, which suggests a closed set.
+func (s *Shape) Enum(max int) []string {
+	if s.manyValues || len(s.Values) == 0 || len(s.Values) > max || s.Strings <= len(s.Values) {
+		return nil
+	}
+	return s.Values
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func detectFormat(v string) string {
+	if v == "" {
+		return ""
+	}
+	if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return "date-time"
+	}
+	if _, err := time.Parse(time.DateOnly, v); err == nil {
+		return "date"
+	}
+	if uuidRe.MatchString(v) {
+		return "uuid"
+	}
+	if ip := net.ParseIP(v); ip != nil {
+		if strings.Contains(v, ":") {
+			return "ipv6"
+		}
+		return "ipv4"
+	}
+	if strings.Contains(v, "@") && !strings.ContainsAny(v, " <>") {
+		if addr, err := mail.ParseAddress(v); err == nil && addr.Address == v {
+			return "email"
+		}
+	}
+	if u, err := url.Parse(v); err == nil && u.Scheme != "" && u.Host != "" && !strings.ContainsAny(v, " ") {
+		return "uri"
+	}
+	return ""
+}
+
+func (s *Shape) mergeTuple(node *parser.Node) {
+	s.arrays++
+	if !s.arraySeen {
+		s.arraySeen = true
+		s.tupleOK = len(node.Children) > 1 && len(node.Children) <= maxTupleLen
+		if s.tupleOK {
+			s.Tuple = make([]*Shape, len(node.Children))
+			for i := range s.Tuple {
+				s.Tuple[i] = &Shape{}
+			}
+		}
+	} else if len(node.Children) != len(s.Tuple) {
+		s.tupleOK = false
+	}
+	if !s.tupleOK {
+		s.Tuple = nil
+		return
+	}
+	for i, child := range node.Children {
+		s.Tuple[i].Merge(child)
+	}
+}
+
+func (s *Shape) addType(t parser.NodeType) {
+	for _, existing := range s.Types {
+		if existing == t {
+			return
+		}
+	}
+	s.Types = append(s.Types, t)
+}
+
+// Has reports whether t was seen among the samples.
+func (s *Shape) Has(t parser.NodeType) bool {
+	for _, existing := range s.Types {
+		if existing == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Nullable reports whether null was seen alongside other types.
+func (s *Shape) Nullable() bool {
+	return s.Has(parser.TypeNull) && len(s.Types) > 1
+}
+
+// NonNullTypes returns Types without null. A shape that is only ever null
+// returns TypeNull.
+func (s *Shape) NonNullTypes() []parser.NodeType {
+	out := []parser.NodeType{}
+	for _, t := range s.Types {
+		if t != parser.TypeNull {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 && len(s.Types) > 0 {
+		out = append(out, parser.TypeNull)
+	}
+	return out
+}
+
+// Optional reports whether the field is missing from some objects.
+func (f *Field) Optional(parent *Shape) bool {
+	return f.Present < parent.Objects
+}
+
+// IsTuple reports whether the array elements differ in type by position
+// but are consistent across samples, e.g. [string, number]. It takes
+// several arrays of the same length, each position holding one type that
+// is never null; anything else, such as a nullable or homogeneous array,
+// is described by the merged Items shape.
+func (s *Shape) IsTuple() bool {
+	if !s.tupleOK || s.arrays < minTupleSamples || s.Items == nil || len(s.Items.Types) < 2 || s.Items.Has(parser.TypeNull) {
+		return false
+	}
+	for _, item := range s.Tuple {
+		if len(item.Types) != 1 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package pipe
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+func formatSchema(t *testing.T, input string) string {
+	t.Helper()
+	root, err := parser.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := NewSchemaFormatter(Colorizer{}).Format(&out, root); err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func TestSchemaArrayShapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"nullable", `["a",null,"b"]`, `[string|null]`},
+		{"homogeneous", `[1,2,3]`, `[number]`},
+		{"single heterogeneous sample", `[1,"x"]`, `[number|string]`},
+		{"objects with null", `[{"id":1,"name":"a"},{"id":2},null]`, "[{\n    \"id\": number,\n    \"name\"?: string (1/2)\n  }|null]"},
+		{"stable tuple", `[[1,"x"],[2,"y"]]`, `[[number, string]]`},
+		{"tuple with null position", `[[1,"x"],[2,null]]`, `[[number|string|null]]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSchema(t, tt.input); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaTupleAllowsLongerArrays(t *testing.T) {
+	root, err := parser.Parse(strings.NewReader(`[[1,"x"],[2,"y"]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := NewJSONSchemaFormatter(Colorizer{}).Format(&out, root); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"prefixItems"`) {
+		t.Fatalf("expected prefixItems in\n%s", out.String())
+	}
+	if strings.Contains(out.String(), `"items": false`) {
+		t.Errorf("tuple schema rejects longer arrays:\n%s", out.String())
+	}
+}
+
+func TestJSONSchemaKeepsNumberBounds(t *testing.T) {
+	samples := []*parser.Node{}
+	for _, input := range []string{`{"n":9007199254740993,"f":1.50}`, `{"n":9007199254740995,"f":-2e3}`} {
+		root, err := parser.Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, root)
+	}
+	var out bytes.Buffer
+	if err := NewJSONSchemaFormatter(Colorizer{}).FormatSamples(&out, samples); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"minimum": 9007199254740993`, `"maximum": 9007199254740995`,
+		`"minimum": -2e3`, `"maximum": 1.50`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("schema lacks %s:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package pipe
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+const (
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	// enumMaxValues is the most distinct strings emitted as an enum.
+	enumMaxValues = 8
+)
+
+// JSONSchemaFormatter infers a JSON Schema (draft 2020-12) document from
+// sample data and prints it as colorized JSON.
+type JSONSchemaFormatter struct {
+	pretty *PrettyFormatter
+}
+
+func NewJSONSchemaFormatter(color Colorizer) *JSONSchemaFormatter {
+	return &JSONSchemaFormatter{pretty: NewPrettyFormatter(color)}
+}
+
+func (f *JSONSchemaFormatter) Format(w io.Writer, root *parser.Node) error {
+	return f.FormatSamples(w, []*parser.Node{root})
+}
+
+func (f *JSONSchemaFormatter) FormatSamples(w io.Writer, samples []*parser.Node) error {
+	return f.pretty.Format(w, JSONSchema(InferShape(samples...)))
+}
+
+// JSONSchema converts an inferred shape into a JSON Schema document.
+func JSONSchema(shape *Shape) *parser.Node {
+	doc := newObjectNode()
+	setField(doc, "$schema", newStringNode(jsonSchemaDraft))
+	schema := schemaForShape(shape)
+	for _, child := range schema.Children {
+		setField(doc, child.Key, child)
+	}
+	return doc
+}
+
+func schemaForShape(shape *Shape) *parser.Node {
+	schema := newObjectNode()
+	types := shape.NonNullTypes()
+	if shape.Nullable() {
+		types = append(types, parser.TypeNull)
+	}
+	names := make([]*parser.Node, 0, len(types))
+	for _, t := range types {
+		name := string(t)
+		if t == parser.TypeNumber && shape.IntegerOnly() {
+			name = "integer"
+		}
+		names = append(names, newStringNode(name))
+	}
+	switch len(names) {
+	case 0:
+		return schema
+	case 1:
+		setField(schema, "type", names[0])
+	default:
+		setField(schema, "type", newArrayNode(names...))
+	}
+
+	if shape.Has(parser.TypeString) {
+		if format := shape.Format(); format != "" {
+			setField(schema, "format", newStringNode(format))
+		} else if enum := shape.Enum(enumMaxValues); enum != nil && len(shape.NonNullTypes()) == 1 {
+			values := make([]*parser.Node, 0, len(enum)+1)
+			for _, v := range enum {
+				values = append(values, newStringNode(v))
+			}
+			if shape.Nullable() {
+				values = append(values, &parser.Node{Type: parser.TypeNull})
+			}
+			setField(schema, "enum", newArrayNode(values...))
+		}
+	}
+	if shape.Numbers > 0 {
+		setField(schema, "minimum", newNumberNode(shape.Min))
+		setField(schema, "maximum", newNumberNode(shape.Max))
+	}
+	if shape.Has(parser.TypeObject) {
+		props := newObjectNode()
+		required := []*parser.Node{}
+		for _, field := range shape.Fields {
+			setField(props, field.Name, schemaForShape(field.Shape))
+			if !field.Optional(shape) {
+				required = append(required, newStringNode(field.Name))
+			}
+		}
+		setField(schema, "properties", props)
+		if len(required) > 0 {
+			setField(schema, "required", newArrayNode(required...))
+		}
+	}
+	if shape.Has(parser.TypeArray) && shape.Items != nil && len(shape.Items.Types) > 0 {
+		if shape.IsTuple() {
+			items := make([]*parser.Node, 0, len(shape.Tuple))
+			for _, item := range shape.Tuple {
+				items = append(items, schemaForShape(item))
+			}
+			setField(schema, "prefixItems", newArrayNode(items...))
+			// Longer arrays are not rejected; their extra elements take
+			// any of the kinds seen.
+			setField(schema, "items", schemaForShape(shape.Items))
+		} else {
+			setField(schema, "items", schemaForShape(shape.Items))
+		}
+	}
+	return schema
+}
+
+func newObjectNode() *parser.Node {
+	return &parser.Node{Key: "root", Type: parser.TypeObject}
+}
+
+func newArrayNode(items ...*parser.Node) *parser.Node {
+	node := &parser.Node{Key: "root", Type: parser.TypeArray}
+	for i, item := range items {
+		item.Key = strconv.Itoa(i)
+		item.Parent = node
+		node.Children = append(node.Children, item)
+	}
+	return node
+}
+
+func newStringNode(s string) *parser.Node {
+	return &parser.Node{Type: parser.TypeString, Value: s}
+}
+
+func newNumberNode(v json.Number) *parser.Node {
+	return &parser.Node{Type: parser.TypeNumber, Value: v.String()}
+}
+
+func setField(obj *parser.Node, key string, value *parser.Node) {
+	value.Key = key
+	value.Parent = obj
+	obj.Children = append(obj.Children, value)
+}
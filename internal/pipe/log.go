@@ -0,0 +1,85 @@
+package pipe
+
+import (
+	"io"
+	"strings"
+
+	"github.com/simota/jv/internal/logs"
+	"github.com/simota/jv/internal/parser"
+)
+
+// LogFormatter prints log records one per line as
+// `TIME LEVEL message key=value ...`, with the level colored by severity.
+// The root is an array of records or a single record.
+type LogFormatter struct {
+	color  Colorizer
+	fields logs.Fields
+	// minLevel hides records below this rank in logs.Levels.
+	minLevel int
+}
+
+func NewLogFormatter(color Colorizer, fields logs.Fields, minLevel int) *LogFormatter {
+	return &LogFormatter{color: color, fields: fields, minLevel: minLevel}
+}
+
+func (f *LogFormatter) Format(w io.Writer, root *parser.Node) error {
+	buf := newWriter(w)
+	records := []*parser.Node{root}
+	if root.Type == parser.TypeArray {
+		records = root.Children
+	}
+	for _, record := range records {
+		if buf.err != nil {
+			break
+		}
+		f.writeRecord(buf, record)
+	}
+	return buf.Flush()
+}
+
+func (f *LogFormatter) writeRecord(buf *writer, node *parser.Node) {
+	r := logs.Summarize(node, f.fields)
+	if !logs.Visible(r.Level, f.minLevel) {
+		return
+	}
+	if r.Time != "" {
+		buf.WriteString(f.color.TypeHint(r.Time))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(f.level(r))
+	if r.Message != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(r.Message)
+	}
+	if node.Type == parser.TypeObject {
+		for _, child := range node.Children {
+			if r.Fields[child] {
+				continue
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(f.color.Key(child.Key))
+			buf.WriteByte('=')
+			buf.WriteString(compactValue(child, needsQuote(child)))
+		}
+	}
+	buf.WriteByte('\n')
+}
+
+func (f *LogFormatter) level(r logs.Record) string {
+	switch r.Level {
+	case "error", "fatal":
+		return f.color.Removed(r.Label())
+	case "warn":
+		return f.color.Changed(r.Label())
+	case "info":
+		return f.color.Added(r.Label())
+	}
+	return f.color.TypeHint(r.Label())
+}
+
+// needsQuote keeps key=value pairs unambiguous: strings are quoted when
+// empty or when they contain spaces, quotes or an equals sign.
+func needsQuote(node *parser.Node) bool {
+	s, ok := node.Value.(string)
+	return ok && (s == "" || strings.ContainsAny(s, " \t\"="))
+}
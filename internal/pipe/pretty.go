@@ -1,7 +1,7 @@
 package pipe
 
 import (
-	"bytes"
+	"io"
 	"strconv"
 	"strings"
 
@@ -12,18 +12,18 @@ type PrettyFormatter struct {
 	color Colorizer
 }
 
-func NewPrettyFormatter(colorEnabled bool) *PrettyFormatter {
-	return &PrettyFormatter{color: Colorizer{Enabled: colorEnabled}}
+func NewPrettyFormatter(color Colorizer) *PrettyFormatter {
+	return &PrettyFormatter{color: color}
 }
 
-func (f *PrettyFormatter) Format(root *parser.Node) string {
-	var buf bytes.Buffer
-	f.writeNode(&buf, root, 0)
+func (f *PrettyFormatter) Format(w io.Writer, root *parser.Node) error {
+	buf := newWriter(w)
+	f.writeNode(buf, root, 0)
 	buf.WriteByte('\n')
-	return buf.String()
+	return buf.Flush()
 }
 
-func (f *PrettyFormatter) writeNode(buf *bytes.Buffer, node *parser.Node, depth int) {
+func (f *PrettyFormatter) writeNode(buf *writer, node *parser.Node, depth int) {
 	switch node.Type {
 	case parser.TypeObject:
 		f.writeObject(buf, node, depth)
@@ -34,7 +34,7 @@ func (f *PrettyFormatter) writeNode(buf *bytes.Buffer, node *parser.Node, depth
 	}
 }
 
-func (f *PrettyFormatter) writeObject(buf *bytes.Buffer, node *parser.Node, depth int) {
+func (f *PrettyFormatter) writeObject(buf *writer, node *parser.Node, depth int) {
 	buf.WriteString("{")
 	if len(node.Children) == 0 {
 		buf.WriteString("}")
@@ -43,6 +43,9 @@ func (f *PrettyFormatter) writeObject(buf *bytes.Buffer, node *parser.Node, dept
 	buf.WriteByte('\n')
 	indent := strings.Repeat("  ", depth+1)
 	for i, child := range node.Children {
+		if buf.err != nil {
+			return
+		}
 		buf.WriteString(indent)
 		buf.WriteString(f.color.Key(strconv.Quote(child.Key)))
 		buf.WriteString(": ")
@@ -56,7 +59,7 @@ func (f *PrettyFormatter) writeObject(buf *bytes.Buffer, node *parser.Node, dept
 	buf.WriteString("}")
 }
 
-func (f *PrettyFormatter) writeArray(buf *bytes.Buffer, node *parser.Node, depth int) {
+func (f *PrettyFormatter) writeArray(buf *writer, node *parser.Node, depth int) {
 	buf.WriteString("[")
 	if len(node.Children) == 0 {
 		buf.WriteString("]")
@@ -65,6 +68,9 @@ func (f *PrettyFormatter) writeArray(buf *bytes.Buffer, node *parser.Node, depth
 	buf.WriteByte('\n')
 	indent := strings.Repeat("  ", depth+1)
 	for i, child := range node.Children {
+		if buf.err != nil {
+			return
+		}
 		buf.WriteString(indent)
 		f.writeNode(buf, child, depth+1)
 		if i < len(node.Children)-1 {
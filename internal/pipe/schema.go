@@ -1,53 +1,79 @@
 package pipe
 
 import (
-	"bytes"
+	"io"
 	"strconv"
 	"strings"
 
 	"github.com/simota/jv/internal/parser"
 )
 
+// SchemaFormatter renders the inferred shape of a document. Array
+// elements are merged into a single shape: fields missing from some
+// elements are marked with `?` and their presence count, mixed types are
+// shown as unions such as `string|null`, and fixed-length arrays whose
+// element types differ by position are shown as tuples.
 type SchemaFormatter struct {
 	color Colorizer
 }
 
-func NewSchemaFormatter(colorEnabled bool) *SchemaFormatter {
-	return &SchemaFormatter{color: Colorizer{Enabled: colorEnabled}}
+func NewSchemaFormatter(color Colorizer) *SchemaFormatter {
+	return &SchemaFormatter{color: color}
 }
 
-func (f *SchemaFormatter) Format(root *parser.Node) string {
-	var buf bytes.Buffer
-	f.writeSchema(&buf, root, 0)
+func (f *SchemaFormatter) Format(w io.Writer, root *parser.Node) error {
+	buf := newWriter(w)
+	f.writeShape(buf, InferShape(root), 0)
 	buf.WriteByte('\n')
-	return buf.String()
+	return buf.Flush()
 }
 
-func (f *SchemaFormatter) writeSchema(buf *bytes.Buffer, node *parser.Node, depth int) {
-	switch node.Type {
-	case parser.TypeObject:
-		f.writeSchemaObject(buf, node, depth)
-	case parser.TypeArray:
-		f.writeSchemaArray(buf, node, depth)
-	default:
-		buf.WriteString(f.color.TypeHint(string(node.Type)))
+func (f *SchemaFormatter) writeShape(buf *writer, shape *Shape, depth int) {
+	types := shape.NonNullTypes()
+	if shape.Nullable() {
+		types = append(types, parser.TypeNull)
+	}
+	if len(types) == 0 {
+		buf.WriteString(f.color.TypeHint("unknown"))
+		return
+	}
+	for i, t := range types {
+		if i > 0 {
+			buf.WriteString(f.color.TypeHint("|"))
+		}
+		switch t {
+		case parser.TypeObject:
+			f.writeSchemaObject(buf, shape, depth)
+		case parser.TypeArray:
+			f.writeSchemaArray(buf, shape, depth)
+		default:
+			buf.WriteString(f.color.TypeHint(string(t)))
+		}
 	}
 }
 
-func (f *SchemaFormatter) writeSchemaObject(buf *bytes.Buffer, node *parser.Node, depth int) {
+func (f *SchemaFormatter) writeSchemaObject(buf *writer, shape *Shape, depth int) {
 	buf.WriteString("{")
-	if len(node.Children) == 0 {
+	if len(shape.Fields) == 0 {
 		buf.WriteString("}")
 		return
 	}
 	buf.WriteByte('\n')
 	indent := strings.Repeat("  ", depth+1)
-	for i, child := range node.Children {
+	for i, field := range shape.Fields {
 		buf.WriteString(indent)
-		buf.WriteString(f.color.Key(strconv.Quote(child.Key)))
+		buf.WriteString(f.color.Key(strconv.Quote(field.Name)))
+		optional := field.Optional(shape)
+		if optional {
+			buf.WriteString(f.color.TypeHint("?"))
+		}
 		buf.WriteString(": ")
-		f.writeSchema(buf, child, depth+1)
-		if i < len(node.Children)-1 {
+		f.writeShape(buf, field.Shape, depth+1)
+		if optional {
+			buf.WriteString(" ")
+			buf.WriteString(f.color.TypeHint("(" + itoa(field.Present) + "/" + itoa(shape.Objects) + ")"))
+		}
+		if i < len(shape.Fields)-1 {
 			buf.WriteString(",")
 		}
 		buf.WriteByte('\n')
@@ -56,12 +82,21 @@ func (f *SchemaFormatter) writeSchemaObject(buf *bytes.Buffer, node *parser.Node
 	buf.WriteString("}")
 }
 
-func (f *SchemaFormatter) writeSchemaArray(buf *bytes.Buffer, node *parser.Node, depth int) {
-	if len(node.Children) == 0 {
+func (f *SchemaFormatter) writeSchemaArray(buf *writer, shape *Shape, depth int) {
+	if shape.Items == nil || len(shape.Items.Types) == 0 {
 		buf.WriteString("[]")
 		return
 	}
 	buf.WriteString("[")
-	f.writeSchema(buf, node.Children[0], depth+1)
+	if shape.IsTuple() {
+		for i, item := range shape.Tuple {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			f.writeShape(buf, item, depth+1)
+		}
+	} else {
+		f.writeShape(buf, shape.Items, depth+1)
+	}
 	buf.WriteString("]")
 }
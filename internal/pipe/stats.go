@@ -0,0 +1,167 @@
+package pipe
+
+import (
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/simota/jv/internal/parser"
+)
+
+// DefaultStatsTop is the number of entries in the longest-array and
+// heaviest-path lists.
+const DefaultStatsTop = 10
+
+var statsTypes = []parser.NodeType{
+	parser.TypeObject,
+	parser.TypeArray,
+	parser.TypeString,
+	parser.TypeNumber,
+	parser.TypeBoolean,
+	parser.TypeNull,
+}
+
+// StatsFormatter reports what a document is made of: node counts by type,
+// nesting depth, the longest arrays and the subtrees taking the most bytes
+// when serialized compactly.
+type StatsFormatter struct {
+	color Colorizer
+	top   int
+}
+
+func NewStatsFormatter(color Colorizer, top int) *StatsFormatter {
+	if top <= 0 {
+		top = DefaultStatsTop
+	}
+	return &StatsFormatter{color: color, top: top}
+}
+
+type statsEntry struct {
+	node  *parser.Node
+	value int
+}
+
+func (f *StatsFormatter) Format(w io.Writer, root *parser.Node) error {
+	extents := parser.Measure(root)
+	counts := map[parser.NodeType]int{}
+	maxDepth := 0
+	arrays := []statsEntry{}
+	heaviest := []statsEntry{}
+	for node, ext := range extents {
+		counts[node.Type]++
+		if d := node.Depth - root.Depth; d > maxDepth {
+			maxDepth = d
+		}
+		if node.Type == parser.TypeArray {
+			arrays = append(arrays, statsEntry{node: node, value: len(node.Children)})
+		}
+		if node != root {
+			heaviest = append(heaviest, statsEntry{node: node, value: ext.Bytes})
+		}
+	}
+	total := extents[root]
+
+	sections := []statsSection{{"Summary", [][]string{
+		{"Size (compact)", parser.FormatBytes(total.Bytes)},
+		{"Bytes", strconv.Itoa(total.Bytes)},
+		{"Nodes", strconv.Itoa(total.Nodes)},
+		{"Max depth", strconv.Itoa(maxDepth)},
+	}}}
+
+	rows := [][]string{}
+	for _, t := range statsTypes {
+		if counts[t] > 0 {
+			rows = append(rows, []string{string(t), strconv.Itoa(counts[t])})
+		}
+	}
+	sections = append(sections, statsSection{"Types", rows})
+
+	if len(arrays) > 0 {
+		rows = [][]string{}
+		for _, e := range f.topEntries(arrays) {
+			rows = append(rows, []string{e.node.Path(), strconv.Itoa(e.value) + " items"})
+		}
+		sections = append(sections, statsSection{"Longest arrays", rows})
+	}
+
+	if len(heaviest) > 0 {
+		rows = [][]string{}
+		for _, e := range f.topEntries(heaviest) {
+			share := 100 * float64(e.value) / float64(total.Bytes)
+			rows = append(rows, []string{e.node.Path(), parser.FormatBytes(e.value), strconv.FormatFloat(share, 'f', 1, 64) + "%"})
+		}
+		sections = append(sections, statsSection{"Heaviest paths", rows})
+	}
+
+	b := newWriter(w)
+	widths := columnWidths(sections)
+	for i, section := range sections {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		f.writeSection(b, section, widths)
+	}
+	return b.Flush()
+}
+
+// topEntries returns the f.top entries with the largest values, breaking
+// ties by path so the report is stable.
+func (f *StatsFormatter) topEntries(entries []statsEntry) []statsEntry {
+	paths := make(map[*parser.Node]string, len(entries))
+	for _, e := range entries {
+		paths[e.node] = e.node.Path()
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].value != entries[j].value {
+			return entries[i].value > entries[j].value
+		}
+		return paths[entries[i].node] < paths[entries[j].node]
+	})
+	if len(entries) > f.top {
+		entries = entries[:f.top]
+	}
+	return entries
+}
+
+// statsSection is a titled block of rows: a label or path followed by
+// values.
+type statsSection struct {
+	title string
+	rows  [][]string
+}
+
+// columnWidths returns the width of each column across all sections, so
+// that labels and values line up from one section to the next.
+func columnWidths(sections []statsSection) []int {
+	widths := []int{}
+	for _, section := range sections {
+		for _, row := range section.rows {
+			for i, cell := range row {
+				if i >= len(widths) {
+					widths = append(widths, 0)
+				}
+				if w := runewidth.StringWidth(cell); w > widths[i] {
+					widths[i] = w
+				}
+			}
+		}
+	}
+	return widths
+}
+
+// writeSection renders rows as aligned columns: the first one, a label or
+// path, left-aligned and the values after it right-aligned.
+func (f *StatsFormatter) writeSection(b *writer, section statsSection, widths []int) {
+	b.WriteString(f.color.Key(section.title + ":"))
+	b.WriteByte('\n')
+	for _, row := range section.rows {
+		b.WriteString("  ")
+		b.WriteString(runewidth.FillRight(row[0], widths[0]))
+		for i, cell := range row[1:] {
+			b.WriteString("  ")
+			b.WriteString(f.color.Number(runewidth.FillLeft(cell, widths[i+1])))
+		}
+		b.WriteByte('\n')
+	}
+}
@@ -0,0 +1,39 @@
+package pipe
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+func TestStatsAlignsSections(t *testing.T) {
+	root, err := parser.Parse(strings.NewReader(`{"items":[1,2,{"name":"a long string value"}],"n":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := NewStatsFormatter(Colorizer{}, 0).Format(&out, root); err != nil {
+		t.Fatal(err)
+	}
+	// The first value of every row ends in the same column.
+	end := -1
+	for _, line := range strings.Split(out.String(), "\n") {
+		if !strings.HasPrefix(line, "  ") {
+			continue
+		}
+		fields := strings.SplitN(strings.TrimSpace(line), "  ", 2)
+		value := strings.TrimLeft(fields[1], " ")
+		if i := strings.Index(value, "  "); i >= 0 {
+			value = value[:i]
+		}
+		col := strings.Index(line, value) + len(value)
+		if end < 0 {
+			end = col
+		}
+		if col != end {
+			t.Errorf("value of %q ends at %d, want %d\n%s", line, col, end, out.String())
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package pipe
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/simota/jv/internal/parser"
+)
+
+const (
+	tableColumnGap = 2
+	tableMinWidth  = 4
+	tableEllipsis  = "…"
+)
+
+type TableOptions struct {
+	// Columns restricts and orders the rendered columns. Empty means the
+	// union of keys over all rows, in first-seen order.
+	Columns []string
+	// Width is the maximum line width. Zero disables truncation.
+	Width int
+}
+
+type TableFormatter struct {
+	color Colorizer
+	opts  TableOptions
+}
+
+func NewTableFormatter(color Colorizer, opts TableOptions) *TableFormatter {
+	return &TableFormatter{color: color, opts: opts}
+}
+
+func (f *TableFormatter) Check(node *parser.Node) error {
+	if node.Type != parser.TypeArray {
+		return errors.New("table mode requires an array at " + node.Path() + ", got " + string(node.Type))
+	}
+	return nil
+}
+
+type tableCell struct {
+	text string
+	node *parser.Node
+}
+
+func (f *TableFormatter) Format(w io.Writer, root *parser.Node) error {
+	columns := f.columns(root)
+	rows := make([][]tableCell, 0, len(root.Children))
+	for _, row := range root.Children {
+		cells := make([]tableCell, len(columns))
+		for i, col := range columns {
+			if cell := tableLookup(row, col); cell != nil {
+				cells[i] = tableCell{text: compactValue(cell, false), node: cell}
+			}
+		}
+		rows = append(rows, cells)
+	}
+
+	widths := make([]int, len(columns))
+	for i, col := range columns {
+		widths[i] = runewidth.StringWidth(col)
+	}
+	for _, cells := range rows {
+		for i, cell := range cells {
+			if w := runewidth.StringWidth(cell.text); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	fitWidths(widths, f.opts.Width)
+
+	b := newWriter(w)
+	for i, col := range columns {
+		f.writeCell(b, col, widths[i], i == len(columns)-1, f.color.Key)
+	}
+	b.WriteByte('\n')
+	for i := range columns {
+		rule := strings.Repeat("-", widths[i])
+		f.writeCell(b, rule, widths[i], i == len(columns)-1, f.color.TypeHint)
+	}
+	b.WriteByte('\n')
+	for _, cells := range rows {
+		if b.err != nil {
+			break
+		}
+		for i, cell := range cells {
+			f.writeCell(b, cell.text, widths[i], i == len(cells)-1, f.cellColor(cell.node))
+		}
+		b.WriteByte('\n')
+	}
+	return b.Flush()
+}
+
+func (f *TableFormatter) columns(root *parser.Node) []string {
+	if len(f.opts.Columns) > 0 {
+		return f.opts.Columns
+	}
+	seen := map[string]bool{}
+	columns := []string{}
+	for _, row := range root.Children {
+		if row.Type != parser.TypeObject {
+			if !seen[tableValueColumn] {
+				seen[tableValueColumn] = true
+				columns = append(columns, tableValueColumn)
+			}
+			continue
+		}
+		for _, child := range row.Children {
+			if !seen[child.Key] {
+				seen[child.Key] = true
+				columns = append(columns, child.Key)
+			}
+		}
+	}
+	return columns
+}
+
+// tableValueColumn holds array elements that are not objects.
+const tableValueColumn = "value"
+
+func tableLookup(row *parser.Node, column string) *parser.Node {
+	if row.Type != parser.TypeObject {
+		if column == tableValueColumn {
+			return row
+		}
+		return nil
+	}
+	for _, child := range row.Children {
+		if child.Key == column {
+			return child
+		}
+	}
+	if strings.Contains(column, ".") {
+		if node, err := parser.Find(row, column); err == nil {
+			return node
+		}
+	}
+	return nil
+}
+
+func (f *TableFormatter) writeCell(b *writer, text string, width int, last bool, paint func(string) string) {
+	if runewidth.StringWidth(text) > width {
+		text = runewidth.Truncate(text, width, tableEllipsis)
+	}
+	b.WriteString(paint(text))
+	if last {
+		return
+	}
+	pad := width - runewidth.StringWidth(text) + tableColumnGap
+	b.WriteString(strings.Repeat(" ", pad))
+}
+
+func (f *TableFormatter) cellColor(node *parser.Node) func(string) string {
+	if node == nil {
+		return func(s string) string { return s }
+	}
+	switch node.Type {
+	case parser.TypeString:
+		return f.color.String
+	case parser.TypeNumber:
+		return f.color.Number
+	case parser.TypeBoolean:
+		return f.color.Boolean
+	case parser.TypeNull:
+		return f.color.Null
+	default:
+		return f.color.TypeHint
+	}
+}
+
+// fitWidths shrinks the widest columns until the table fits in max.
+func fitWidths(widths []int, max int) {
+	if max <= 0 || len(widths) == 0 {
+		return
+	}
+	total := func() int {
+		sum := tableColumnGap * (len(widths) - 1)
+		for _, w := range widths {
+			sum += w
+		}
+		return sum
+	}
+	for total() > max {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= tableMinWidth {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// compactValue renders node on a single line. Top-level strings are left
+// unquoted when quote is false.
+func compactValue(node *parser.Node, quote bool) string {
+	switch node.Type {
+	case parser.TypeObject:
+		parts := make([]string, 0, len(node.Children))
+		for _, child := range node.Children {
+			parts = append(parts, strconv.Quote(child.Key)+":"+compactValue(child, true))
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	case parser.TypeArray:
+		parts := make([]string, 0, len(node.Children))
+		for _, child := range node.Children {
+			parts = append(parts, compactValue(child, true))
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	case parser.TypeString:
+		if !quote {
+			if v, ok := node.Value.(string); ok {
+				return strings.ReplaceAll(v, "\n", `\n`)
+			}
+		}
+		return node.StringValue()
+	default:
+		return node.StringValue()
+	}
+}
@@ -0,0 +1,169 @@
+package pipe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+type TOMLFormatter struct {
+	color Colorizer
+}
+
+func NewTOMLFormatter(color Colorizer) *TOMLFormatter {
+	return &TOMLFormatter{color: color}
+}
+
+func (f *TOMLFormatter) Check(root *parser.Node) error {
+	if root.Type != parser.TypeObject {
+		return errors.New("toml output requires an object at the root, got " + string(root.Type))
+	}
+	return checkNoNull(root, "toml")
+}
+
+func checkNoNull(node *parser.Node, format string) error {
+	if node.Type == parser.TypeNull {
+		return fmt.Errorf("%s cannot represent null at %s", format, node.Path())
+	}
+	for _, child := range node.Children {
+		if err := checkNoNull(child, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *TOMLFormatter) Format(w io.Writer, root *parser.Node) error {
+	buf := newWriter(w)
+	f.writeTable(buf, root, nil)
+	return buf.Flush()
+}
+
+// writeTable writes the inline key/value pairs of node followed by its
+// sub-tables and arrays of tables. path holds the keys of the table header.
+func (f *TOMLFormatter) writeTable(buf *writer, node *parser.Node, path []string) {
+	for _, child := range node.Children {
+		if isTOMLTable(child) || isTOMLTableArray(child) {
+			continue
+		}
+		buf.WriteString(f.color.Key(tomlKey(child.Key)))
+		buf.WriteString(" = ")
+		buf.WriteString(f.inline(child))
+		buf.WriteByte('\n')
+	}
+	for _, child := range node.Children {
+		if buf.err != nil {
+			return
+		}
+		childPath := append(append([]string{}, path...), tomlKey(child.Key))
+		switch {
+		case isTOMLTable(child):
+			f.writeHeader(buf, "["+strings.Join(childPath, ".")+"]")
+			f.writeTable(buf, child, childPath)
+		case isTOMLTableArray(child):
+			for _, item := range child.Children {
+				f.writeHeader(buf, "[["+strings.Join(childPath, ".")+"]]")
+				f.writeTable(buf, item, childPath)
+			}
+		}
+	}
+}
+
+func (f *TOMLFormatter) writeHeader(buf *writer, header string) {
+	if buf.n > 0 {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(f.color.Key(header))
+	buf.WriteByte('\n')
+}
+
+func (f *TOMLFormatter) inline(node *parser.Node) string {
+	switch node.Type {
+	case parser.TypeObject:
+		parts := make([]string, 0, len(node.Children))
+		for _, child := range node.Children {
+			parts = append(parts, f.color.Key(tomlKey(child.Key))+" = "+f.inline(child))
+		}
+		if len(parts) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(parts, ", ") + " }"
+	case parser.TypeArray:
+		parts := make([]string, 0, len(node.Children))
+		for _, child := range node.Children {
+			parts = append(parts, f.inline(child))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case parser.TypeString:
+		v, _ := node.Value.(string)
+		return f.color.String(tomlQuote(v))
+	case parser.TypeNumber:
+		return f.color.Number(node.StringValue())
+	case parser.TypeBoolean:
+		return f.color.Boolean(node.StringValue())
+	default:
+		return node.StringValue()
+	}
+}
+
+// isTOMLTable reports whether node is written as a [table] section.
+func isTOMLTable(node *parser.Node) bool {
+	return node.Type == parser.TypeObject && len(node.Children) > 0
+}
+
+// isTOMLTableArray reports whether node is written as [[array]] sections.
+func isTOMLTableArray(node *parser.Node) bool {
+	if node.Type != parser.TypeArray || len(node.Children) == 0 {
+		return false
+	}
+	for _, child := range node.Children {
+		if child.Type != parser.TypeObject {
+			return false
+		}
+	}
+	return true
+}
+
+var tomlBareKeyRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if tomlBareKeyRe.MatchString(key) {
+		return key
+	}
+	return tomlQuote(key)
+}
+
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -1,7 +1,7 @@
 package pipe
 
 import (
-	"strings"
+	"io"
 
 	"github.com/simota/jv/internal/parser"
 )
@@ -10,17 +10,17 @@ type TypedFormatter struct {
 	color Colorizer
 }
 
-func NewTypedFormatter(colorEnabled bool) *TypedFormatter {
-	return &TypedFormatter{color: Colorizer{Enabled: colorEnabled}}
+func NewTypedFormatter(color Colorizer) *TypedFormatter {
+	return &TypedFormatter{color: color}
 }
 
-func (f *TypedFormatter) Format(root *parser.Node) string {
-	lines := make([]string, 0)
-	f.walk(root, "", true, &lines)
-	return strings.Join(lines, "\n") + "\n"
+func (f *TypedFormatter) Format(w io.Writer, root *parser.Node) error {
+	buf := newWriter(w)
+	f.walk(buf, root, "", true)
+	return buf.Flush()
 }
 
-func (f *TypedFormatter) walk(node *parser.Node, prefix string, isLast bool, lines *[]string) {
+func (f *TypedFormatter) walk(buf *writer, node *parser.Node, prefix string, isLast bool) {
 	linePrefix := prefix
 	if node.Parent != nil {
 		if isLast {
@@ -40,9 +40,10 @@ func (f *TypedFormatter) walk(node *parser.Node, prefix string, isLast bool, lin
 	if typeHint != "" {
 		line = line + "  " + typeHint
 	}
-	*lines = append(*lines, linePrefix+line)
+	buf.WriteString(linePrefix + line)
+	buf.WriteByte('\n')
 
-	if len(node.Children) == 0 {
+	if len(node.Children) == 0 || buf.err != nil {
 		return
 	}
 
@@ -55,7 +56,10 @@ func (f *TypedFormatter) walk(node *parser.Node, prefix string, isLast bool, lin
 		}
 	}
 	for i, child := range node.Children {
-		f.walk(child, nextPrefix, i == len(node.Children)-1, lines)
+		if buf.err != nil {
+			return
+		}
+		f.walk(buf, child, nextPrefix, i == len(node.Children)-1)
 	}
 }
 
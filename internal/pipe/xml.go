@@ -0,0 +1,99 @@
+package pipe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+const (
+	xmlRootElement = "root"
+	xmlItemElement = "item"
+)
+
+type XMLFormatter struct {
+	color Colorizer
+}
+
+func NewXMLFormatter(color Colorizer) *XMLFormatter {
+	return &XMLFormatter{color: color}
+}
+
+var xmlNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+func (f *XMLFormatter) Check(root *parser.Node) error {
+	for _, child := range root.Children {
+		if root.Type == parser.TypeObject {
+			if !xmlNameRe.MatchString(child.Key) || strings.HasPrefix(strings.ToLower(child.Key), "xml") {
+				return fmt.Errorf("xml cannot use key %q as an element name at %s", child.Key, child.Path())
+			}
+		}
+		if err := f.Check(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Format writes the document as elements named after object keys. The
+// document element is <root> and array elements are written as <item>.
+func (f *XMLFormatter) Format(w io.Writer, root *parser.Node) error {
+	buf := newWriter(w)
+	buf.WriteString(f.color.TypeHint(`<?xml version="1.0" encoding="UTF-8"?>`))
+	buf.WriteByte('\n')
+	f.writeElement(buf, xmlRootElement, root, 0)
+	return buf.Flush()
+}
+
+func (f *XMLFormatter) writeElement(buf *writer, name string, node *parser.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	buf.WriteString(indent)
+	if len(node.Children) == 0 {
+		switch node.Type {
+		case parser.TypeObject, parser.TypeArray, parser.TypeNull:
+			buf.WriteString(f.color.Key("<" + name + "/>"))
+		default:
+			buf.WriteString(f.color.Key("<" + name + ">"))
+			buf.WriteString(f.text(node))
+			buf.WriteString(f.color.Key("</" + name + ">"))
+		}
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteString(f.color.Key("<" + name + ">"))
+	buf.WriteByte('\n')
+	for _, child := range node.Children {
+		if buf.err != nil {
+			return
+		}
+		childName := xmlItemElement
+		if node.Type == parser.TypeObject {
+			childName = child.Key
+		}
+		f.writeElement(buf, childName, child, depth+1)
+	}
+	buf.WriteString(indent)
+	buf.WriteString(f.color.Key("</" + name + ">"))
+	buf.WriteByte('\n')
+}
+
+func (f *XMLFormatter) text(node *parser.Node) string {
+	switch node.Type {
+	case parser.TypeString:
+		v, _ := node.Value.(string)
+		var escaped bytes.Buffer
+		_ = xml.EscapeText(&escaped, []byte(v))
+		return f.color.String(escaped.String())
+	case parser.TypeNumber:
+		return f.color.Number(node.StringValue())
+	case parser.TypeBoolean:
+		return f.color.Boolean(node.StringValue())
+	default:
+		return node.StringValue()
+	}
+}
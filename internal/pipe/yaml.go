@@ -0,0 +1,149 @@
+package pipe
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+type YAMLFormatter struct {
+	color Colorizer
+}
+
+func NewYAMLFormatter(color Colorizer) *YAMLFormatter {
+	return &YAMLFormatter{color: color}
+}
+
+func (f *YAMLFormatter) Format(w io.Writer, root *parser.Node) error {
+	buf := newWriter(w)
+	if isEmptyContainer(root) || !isContainer(root) {
+		buf.WriteString(f.scalar(root))
+		buf.WriteByte('\n')
+		return buf.Flush()
+	}
+	f.writeBlock(buf, root, 0, false)
+	return buf.Flush()
+}
+
+// writeBlock writes the children of a non-empty container, one per line,
+// each indented by depth levels. When inline is set the first line
+// continues the current one, after a sequence marker.
+func (f *YAMLFormatter) writeBlock(buf *writer, node *parser.Node, depth int, inline bool) {
+	indent := strings.Repeat("  ", depth)
+	for i, child := range node.Children {
+		if buf.err != nil {
+			return
+		}
+		if i > 0 || !inline {
+			buf.WriteString(indent)
+		}
+		if node.Type == parser.TypeArray {
+			buf.WriteString("-")
+			f.writeArrayItem(buf, child, depth)
+			continue
+		}
+		buf.WriteString(f.color.Key(yamlString(child.Key)))
+		buf.WriteString(":")
+		f.writeValue(buf, child, depth)
+	}
+}
+
+func (f *YAMLFormatter) writeValue(buf *writer, node *parser.Node, depth int) {
+	if isContainer(node) && !isEmptyContainer(node) {
+		buf.WriteByte('\n')
+		f.writeBlock(buf, node, depth+1, false)
+		return
+	}
+	buf.WriteString(" ")
+	buf.WriteString(f.scalar(node))
+	buf.WriteByte('\n')
+}
+
+// writeArrayItem writes a sequence entry after its "-" marker. Nested
+// containers begin on the same line as the marker, YAML compact style.
+func (f *YAMLFormatter) writeArrayItem(buf *writer, node *parser.Node, depth int) {
+	if !isContainer(node) || isEmptyContainer(node) {
+		buf.WriteString(" ")
+		buf.WriteString(f.scalar(node))
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteString(" ")
+	f.writeBlock(buf, node, depth+1, true)
+}
+
+func (f *YAMLFormatter) scalar(node *parser.Node) string {
+	switch node.Type {
+	case parser.TypeObject:
+		return "{}"
+	case parser.TypeArray:
+		return "[]"
+	case parser.TypeString:
+		v, _ := node.Value.(string)
+		return f.color.String(yamlString(v))
+	case parser.TypeNumber:
+		return f.color.Number(node.StringValue())
+	case parser.TypeBoolean:
+		return f.color.Boolean(node.StringValue())
+	case parser.TypeNull:
+		return f.color.Null("null")
+	default:
+		return node.StringValue()
+	}
+}
+
+func isContainer(node *parser.Node) bool {
+	return node.Type == parser.TypeObject || node.Type == parser.TypeArray
+}
+
+func isEmptyContainer(node *parser.Node) bool {
+	return isContainer(node) && len(node.Children) == 0
+}
+
+// yamlString returns s as a plain scalar when that is unambiguous and as a
+// double-quoted scalar otherwise.
+func yamlString(s string) string {
+	if yamlNeedsQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// Plain scalars that YAML 1.1 or 1.2 parsers resolve to numbers or
+// timestamps: hex, octal and binary integers, numbers with underscores,
+// sexagesimal numbers and dates.
+var (
+	yamlNumberRe    = regexp.MustCompile(`^[-+]?(0x[0-9a-fA-F_]+|0o[0-7_]+|0b[01_]+|[0-9][0-9_]*(:[0-5]?[0-9])*(\.[0-9_]*)?([eE][-+]?[0-9]+)?|\.[0-9_]+([eE][-+]?[0-9]+)?)$`)
+	yamlTimestampRe = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt ]|$)`)
+)
+
+func yamlNeedsQuote(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n", ".nan", ".inf", "-.inf", "+.inf":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if yamlNumberRe.MatchString(s) || yamlTimestampRe.MatchString(s) {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == '\u0085' || r == '\u2028' || r == '\u2029' || r == '\ufeff' {
+			return true
+		}
+	}
+	return false
+}
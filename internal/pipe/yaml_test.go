@@ -0,0 +1,56 @@
+package pipe
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+// yamlRoundTrip formats {"k": s} and decodes the scalar back, reporting
+// whether it was quoted.
+func yamlRoundTrip(t *testing.T, s string) (string, bool) {
+	t.Helper()
+	var out bytes.Buffer
+	root := parser.FromValue(map[string]any{"k": s})
+	if err := NewYAMLFormatter(Colorizer{}).Format(&out, root); err != nil {
+		t.Fatal(err)
+	}
+	scalar, ok := strings.CutPrefix(strings.TrimSuffix(out.String(), "\n"), "k: ")
+	if !ok {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	if !strings.HasPrefix(scalar, `"`) {
+		return scalar, false
+	}
+	unquoted, err := strconv.Unquote(scalar)
+	if err != nil {
+		t.Fatalf("bad quoted scalar %s: %v", scalar, err)
+	}
+	return unquoted, true
+}
+
+func TestYAMLQuotesAmbiguousScalars(t *testing.T) {
+	for _, s := range []string{
+		"", "true", "No", "~", "12", "1.5", "-3", "1e3", ".inf", "+.inf",
+		"0x1F", "0o17", "0b101", "017", "1_000", "1_000.5", "190:20:30",
+		"2001-12-14", "2001-12-14t21:59:43.10-05:00", "2001-12-14 21:59:43.10 -5",
+		"- item", "key: value", " padded",
+	} {
+		got, quoted := yamlRoundTrip(t, s)
+		if !quoted || got != s {
+			t.Errorf("%q: got %q, quoted %v", s, got, quoted)
+		}
+	}
+}
+
+func TestYAMLKeepsPlainScalars(t *testing.T) {
+	for _, s := range []string{"hello", "v1.2.3", "0xZZ", "2001-12", "a_b", "12ab", "hello world"} {
+		got, quoted := yamlRoundTrip(t, s)
+		if quoted || got != s {
+			t.Errorf("%q: got %q, quoted %v", s, got, quoted)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package theme
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:embed presets/*.toml
+var presetFiles embed.FS
+
+var builtin = []*Theme{&Dark, &Light}
+
+// defined holds the themes registered with Define.
+var defined = map[string]*Theme{}
+
+// Names lists the built-in themes, the themes registered with Define and
+// the bundled presets.
+func Names() []string {
+	names := make([]string, 0, len(builtin)+len(defined))
+	for _, t := range builtin {
+		names = append(names, t.Name)
+	}
+	custom := make([]string, 0, len(defined))
+	for name := range defined {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	names = append(names, custom...)
+	return append(names, presetNames()...)
+}
+
+// Define registers a theme under name from the decoded contents of a theme
+// file, so that themes can be declared inline in the configuration file.
+func Define(name string, raw map[string]any) error {
+	t, err := fromMap(name, raw)
+	if err != nil {
+		return fmt.Errorf("theme %s: %w", name, err)
+	}
+	t.Name = name
+	defined[name] = t
+	return nil
+}
+
+func presetNames() []string {
+	entries, _ := presetFiles.ReadDir("presets")
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".toml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the theme called spec: a built-in theme, a defined theme, a
+// bundled preset, or a theme file when spec is a path to a .toml or .json
+// file.
+func Load(spec string) (*Theme, error) {
+	if isPath(spec) {
+		return LoadFile(spec)
+	}
+	for _, t := range builtin {
+		if t.Name == spec {
+			return t, nil
+		}
+	}
+	if t, ok := defined[spec]; ok {
+		return t, nil
+	}
+	data, err := presetFiles.ReadFile("presets/" + spec + ".toml")
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme: %s (available: %s, or a .toml/.json file)", spec, strings.Join(Names(), ", "))
+	}
+	return parse(spec, data, "toml")
+}
+
+func isPath(spec string) bool {
+	ext := strings.ToLower(filepath.Ext(spec))
+	return strings.ContainsAny(spec, `/\`) || ext == ".toml" || ext == ".json"
+}
+
+// LoadFile reads a theme file. A leading ~ is expanded to the home
+// directory so that paths from config files work as on the command line.
+func LoadFile(path string) (*Theme, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := "toml"
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = "json"
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	t, err := parse(name, data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+type token struct {
+	key   string
+	style *Style
+}
+
+// tokens maps the keys of a theme file to the styles they set.
+func (t *Theme) tokens() []token {
+	return []token{
+		{"key", &t.Key},
+		{"string", &t.String},
+		{"number", &t.Number},
+		{"boolean", &t.Boolean},
+		{"null", &t.Null},
+		{"type_hint", &t.TypeHint},
+		{"selected_bg", &t.SelectedBg},
+		{"selected_fg", &t.SelectedFg},
+		{"header", &t.Header},
+		{"footer", &t.Footer},
+		{"help", &t.Help},
+		{"error", &t.Error},
+		{"added", &t.Added},
+		{"removed", &t.Removed},
+		{"changed", &t.Changed},
+	}
+}
+
+// parse reads a theme file. Top-level keys are token names whose value is
+// a color, or a table with a color and text attributes:
+//
+//	extends = "dark"
+//	string = "#2aa198"
+//	key = { color = "#268bd2", bold = true }
+//	null = { color = 244, ansi = 8, italic = true }
+//
+// Colors are "#rrggbb" values or 256-color indexes; the lower fidelities
+// are derived from them unless given as ansi256 and ansi. Tokens that are
+// not listed keep the values of the extended theme, dark by default.
+func parse(name string, data []byte, format string) (*Theme, error) {
+	raw := map[string]any{}
+	if format == "json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+	} else if err := toml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return fromMap(name, raw)
+}
+
+func fromMap(name string, raw map[string]any) (*Theme, error) {
+	base := &Dark
+	if v, ok := raw["extends"]; ok {
+		extends, _ := v.(string)
+		found := false
+		for _, t := range builtin {
+			if t.Name == extends {
+				base, found = t, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("extends: unknown theme %v (expected dark or light)", v)
+		}
+	}
+	t := *base
+	t.Name = name
+	if v, ok := raw["name"].(string); ok && v != "" {
+		t.Name = v
+	}
+
+	tokens := t.tokens()
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "name" || key == "extends" {
+			continue
+		}
+		var style *Style
+		known := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			known = append(known, token.key)
+			if token.key == key {
+				style = token.style
+			}
+		}
+		if style == nil {
+			return nil, fmt.Errorf("unknown token %q (expected one of %s)", key, strings.Join(known, ", "))
+		}
+		if err := parseStyle(raw[key], style); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return &t, nil
+}
+
+func parseStyle(v any, style *Style) error {
+	fields, ok := v.(map[string]any)
+	if !ok {
+		c, err := parseColor(v)
+		if err != nil {
+			return err
+		}
+		style.Color = c
+		return nil
+	}
+	if v, ok := fields["color"]; ok {
+		c, err := parseColor(v)
+		if err != nil {
+			return err
+		}
+		style.Color = c
+	}
+	for key, v := range fields {
+		var err error
+		switch key {
+		case "color":
+		case "ansi256":
+			style.ANSI256, err = index(v, 255)
+		case "ansi":
+			style.ANSI, err = index(v, 15)
+		case "bold":
+			style.Bold, err = flag(v)
+		case "italic":
+			style.Italic, err = flag(v)
+		case "underline":
+			style.Underline, err = flag(v)
+		default:
+			err = fmt.Errorf("unknown field %q (expected color, ansi256, ansi, bold, italic or underline)", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flag(v any) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected true or false, got %v", v)
+	}
+	return b, nil
+}
+
+func index(v any, max int) (int, error) {
+	var n float64
+	switch val := v.(type) {
+	case int64:
+		n = float64(val)
+	case float64:
+		n = val
+	case json.Number:
+		f, err := val.Float64()
+		if err != nil {
+			return 0, err
+		}
+		n = f
+	default:
+		return 0, fmt.Errorf("expected a color index, got %v", v)
+	}
+	if n != math.Trunc(n) || n < 0 || n > float64(max) {
+		return 0, fmt.Errorf("color index %v out of range 0-%d", v, max)
+	}
+	return int(n), nil
+}
+
+// parseColor reads a "#rrggbb" (or "#rgb") value or a 256-color index.
+func parseColor(v any) (Color, error) {
+	s, ok := v.(string)
+	if !ok {
+		n, err := index(v, 255)
+		if err != nil {
+			return Color{}, err
+		}
+		return from256(n), nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 || !strings.HasPrefix(s, "#") {
+		return Color{}, fmt.Errorf("invalid color %q (expected #rrggbb or 0-255)", s)
+	}
+	return fromRGB(int(rgb>>16), int(rgb>>8&0xff), int(rgb&0xff)), nil
+}
+
+// ansi16 holds the xterm defaults for the 16 basic colors, used to find
+// the closest one to a truecolor value.
+var ansi16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// rgb256 returns the color of a 256-color index as xterm defines it.
+func rgb256(n int) [3]int {
+	switch {
+	case n < 16:
+		return ansi16[n]
+	case n < 232:
+		n -= 16
+		return [3]int{cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]}
+	default:
+		gray := 8 + (n-232)*10
+		return [3]int{gray, gray, gray}
+	}
+}
+
+func from256(n int) Color {
+	rgb := rgb256(n)
+	c := fromRGB(rgb[0], rgb[1], rgb[2])
+	c.ANSI256 = n
+	if n < 16 {
+		c.ANSI = n
+	}
+	return c
+}
+
+// fromRGB derives the closest 256-color and 16-color indexes. The 256-color
+// search skips the first 16 entries, whose colors vary between terminals.
+func fromRGB(r, g, b int) Color {
+	return Color{
+		ANSI:      nearest(r, g, b, 0, 16),
+		ANSI256:   nearest(r, g, b, 16, 256),
+		TrueColor: fmt.Sprintf("#%02x%02x%02x", r, g, b),
+	}
+}
+
+func nearest(r, g, b, from, to int) int {
+	best, bestDist := from, math.MaxInt
+	for n := from; n < to; n++ {
+		c := rgb256(n)
+		dr, dg, db := c[0]-r, c[1]-g, c[2]-b
+		if dist := dr*dr + dg*dg + db*db; dist < bestDist {
+			best, bestDist = n, dist
+		}
+	}
+	return best
+}
@@ -0,0 +1,187 @@
+package theme
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Profile is the color capability of a terminal.
+type Profile int
+
+const (
+	// ANSI is the 16-color palette, whose actual colors are chosen by the
+	// terminal's own scheme.
+	ANSI Profile = iota
+	ANSI256
+	TrueColor
+)
+
+// DetectProfile reads the color capability from COLORTERM and TERM.
+func DetectProfile() Profile {
+	return ProfileFor(os.Getenv("COLORTERM"), os.Getenv("TERM"))
+}
+
+// ProfileFor maps COLORTERM and TERM values to a profile, falling back to
+// 16 colors when neither advertises more.
+func ProfileFor(colorterm, term string) Profile {
+	switch strings.ToLower(colorterm) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	term = strings.ToLower(term)
+	switch {
+	case strings.Contains(term, "truecolor"), strings.Contains(term, "direct"):
+		return TrueColor
+	case strings.Contains(term, "256color"):
+		return ANSI256
+	}
+	return ANSI
+}
+
+// Color is one color at every fidelity: a 16-color index (0-15), a
+// 256-color index and a "#rrggbb" value.
+type Color struct {
+	ANSI      int
+	ANSI256   int
+	TrueColor string
+}
+
+// Value returns the color at profile p in the form lipgloss.Color
+// accepts: an index such as "6" or "80", or "#56b6c2".
+func (c Color) Value(p Profile) string {
+	switch p {
+	case TrueColor:
+		return c.TrueColor
+	case ANSI256:
+		return strconv.Itoa(c.ANSI256)
+	default:
+		return strconv.Itoa(c.ANSI)
+	}
+}
+
+// Foreground returns the SGR parameters selecting c as the foreground
+// color at profile p, e.g. "36", "38;5;80" or "38;2;86;182;194".
+func (c Color) Foreground(p Profile) string {
+	switch p {
+	case TrueColor:
+		if r, g, b, ok := c.rgb(); ok {
+			return "38;2;" + strconv.Itoa(r) + ";" + strconv.Itoa(g) + ";" + strconv.Itoa(b)
+		}
+	case ANSI256:
+		return "38;5;" + strconv.Itoa(c.ANSI256)
+	}
+	if c.ANSI >= 8 {
+		return strconv.Itoa(90 + c.ANSI - 8)
+	}
+	return strconv.Itoa(30 + c.ANSI)
+}
+
+func (c Color) rgb() (r, g, b int, ok bool) {
+	hex := strings.TrimPrefix(c.TrueColor, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16), int(v >> 8 & 0xff), int(v & 0xff), true
+}
+
+// Attrs are the text attributes of a token.
+type Attrs struct {
+	Bold      bool
+	Italic    bool
+	Underline bool
+}
+
+// Style is the color and attributes of one kind of token.
+type Style struct {
+	Color
+	Attrs
+}
+
+// SGR returns the SGR parameters for s as a foreground at profile p, e.g.
+// "1;38;5;80" for bold 256-color cyan.
+func (s Style) SGR(p Profile) string {
+	params := []string{}
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if s.Italic {
+		params = append(params, "3")
+	}
+	if s.Underline {
+		params = append(params, "4")
+	}
+	return strings.Join(append(params, s.Foreground(p)), ";")
+}
+
+// Theme assigns a style to every kind of token shown by jv, in both pipe
+// and interactive output. SelectedBg is used as a background color.
+type Theme struct {
+	Name       string
+	Key        Style
+	String     Style
+	Number     Style
+	Boolean    Style
+	Null       Style
+	TypeHint   Style
+	SelectedBg Style
+	SelectedFg Style
+	Header     Style
+	Footer     Style
+	Help       Style
+	Error      Style
+	Added      Style
+	Removed    Style
+	Changed    Style
+}
+
+func color(ansi, ansi256 int, hex string) Style {
+	return Style{Color: Color{ANSI: ansi, ANSI256: ansi256, TrueColor: hex}}
+}
+
+// Dark suits terminals with a dark background. Its 16-color values are the
+// classic jv colors.
+var Dark = Theme{
+	Name:       "dark",
+	Key:        color(6, 80, "#56b6c2"),
+	String:     color(2, 114, "#98c379"),
+	Number:     color(3, 180, "#d19a66"),
+	Boolean:    color(5, 176, "#c678dd"),
+	Null:       color(8, 244, "#7f848e"),
+	TypeHint:   color(8, 244, "#7f848e"),
+	SelectedBg: color(4, 75, "#61afef"),
+	SelectedFg: color(0, 16, "#000000"),
+	Header:     color(8, 244, "#7f848e"),
+	Footer:     color(8, 244, "#7f848e"),
+	Help:       color(8, 244, "#7f848e"),
+	Error:      color(1, 203, "#e06c75"),
+	Added:      color(2, 114, "#98c379"),
+	Removed:    color(1, 203, "#e06c75"),
+	Changed:    color(3, 180, "#e5c07b"),
+}
+
+// Light suits terminals with a light background: the 256-color and
+// truecolor values are darker so that strings and numbers stay readable
+// on white.
+var Light = Theme{
+	Name:       "light",
+	Key:        color(6, 30, "#0184bc"),
+	String:     color(2, 28, "#50a14f"),
+	Number:     color(3, 130, "#986801"),
+	Boolean:    color(5, 127, "#a626a4"),
+	Null:       color(8, 242, "#696c77"),
+	TypeHint:   color(8, 242, "#696c77"),
+	SelectedBg: color(12, 153, "#bcd4f5"),
+	SelectedFg: color(0, 16, "#000000"),
+	Header:     color(8, 242, "#696c77"),
+	Footer:     color(8, 242, "#696c77"),
+	Help:       color(8, 242, "#696c77"),
+	Error:      color(1, 160, "#e45649"),
+	Added:      color(2, 28, "#50a14f"),
+	Removed:    color(1, 160, "#e45649"),
+	Changed:    color(3, 130, "#c18401"),
+}
@@ -0,0 +1,526 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/simota/jv/internal/diff"
+	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/theme"
+)
+
+type DiffOptions struct {
+	Theme        string
+	ColorEnabled bool
+	// Profile is the color depth used for the theme's colors.
+	Profile  theme.Profile
+	ArrayKey string
+	OldName  string
+	NewName  string
+	// Keys rebinds actions, see ActionNames.
+	Keys map[string][]string
+}
+
+// DiffModel shows two documents side by side as one aligned tree.
+// Subtrees without differences start collapsed.
+type DiffModel struct {
+	root      *diff.Pair
+	flatPairs []*diff.Pair
+	changes   []*diff.Pair
+	order     map[*diff.Pair]int
+	cursor    int
+	viewport  viewport.Model
+	styles    Styles
+	tokens    Tokens
+	// lines is the rendered tree without the cursor highlight. It is only
+	// rebuilt when the expansion state or the width changes.
+	lines     []string
+	lineIndex map[*diff.Pair]int
+	width     int
+	height    int
+	statusMsg string
+	helpMode  bool
+	pending   string
+	oldName   string
+	newName   string
+	keys      keyMap
+}
+
+func RunDiff(oldRoot, newRoot *parser.Node, opts DiffOptions) error {
+	return runProgram(NewDiffModel(oldRoot, newRoot, opts))
+}
+
+func NewDiffModel(oldRoot, newRoot *parser.Node, opts DiffOptions) DiffModel {
+	tokens := DefaultTokens(opts.Theme, opts.Profile, opts.ColorEnabled)
+	root := diff.Align(oldRoot, newRoot, diff.Options{ArrayKey: opts.ArrayKey})
+	m := DiffModel{
+		root:     root,
+		order:    map[*diff.Pair]int{},
+		styles:   NewStyles(tokens),
+		tokens:   tokens,
+		viewport: viewport.New(0, 0),
+		oldName:  opts.OldName,
+		newName:  opts.NewName,
+		keys:     newKeyMap(opts.Keys, diffScope),
+	}
+	m.index(root)
+	applyDiffExpand(root)
+	m.rebuild()
+	if len(m.changes) > 0 {
+		m.jumpChange(1)
+	}
+	return m
+}
+
+func (m *DiffModel) index(p *diff.Pair) {
+	m.order[p] = len(m.order)
+	if p.Kind != "" {
+		m.changes = append(m.changes, p)
+	}
+	for _, child := range p.Children {
+		m.index(child)
+	}
+}
+
+func applyDiffExpand(p *diff.Pair) {
+	p.Expanded = p.Parent == nil || p.Changes() > 0
+	for _, child := range p.Children {
+		applyDiffExpand(child)
+	}
+}
+
+func flattenPairs(p *diff.Pair) []*diff.Pair {
+	out := []*diff.Pair{p}
+	if !p.Expanded {
+		return out
+	}
+	for _, child := range p.Children {
+		out = append(out, flattenPairs(child)...)
+	}
+	return out
+}
+
+// rebuild renders the tree again after the expansion state or the width
+// changed.
+func (m *DiffModel) rebuild() {
+	m.flatPairs = flattenPairs(m.root)
+	m.lines, m.lineIndex = m.buildLines()
+	m.show()
+}
+
+// show highlights the cursor in the rendered lines and scrolls to it.
+func (m *DiffModel) show() {
+	if m.cursor >= len(m.flatPairs) {
+		m.cursor = len(m.flatPairs) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	selected := m.lineIndex[m.currentPair()]
+	var b strings.Builder
+	for i, line := range m.lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if i == selected {
+			line = m.styles.Selected.Render(line)
+		}
+		b.WriteString(line)
+	}
+	m.viewport.SetContent(b.String())
+	m.ensureCursorVisible()
+}
+
+func (m *DiffModel) ensureCursorVisible() {
+	if m.viewport.Height <= 0 {
+		return
+	}
+	line := m.lineIndex[m.currentPair()]
+	if line < m.viewport.YOffset {
+		m.viewport.YOffset = line
+		return
+	}
+	if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.YOffset = line - m.viewport.Height + 1
+	}
+}
+
+func (m *DiffModel) currentPair() *diff.Pair {
+	if m.cursor < 0 || m.cursor >= len(m.flatPairs) {
+		return m.root
+	}
+	return m.flatPairs[m.cursor]
+}
+
+func (m *DiffModel) setCursorToPair(target *diff.Pair) {
+	for i, p := range m.flatPairs {
+		if p == target {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func (m *DiffModel) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor >= len(m.flatPairs) {
+		m.cursor = len(m.flatPairs) - 1
+	}
+}
+
+// jumpChange moves the cursor to the next (direction > 0) or previous
+// difference, expanding its ancestors.
+func (m *DiffModel) jumpChange(direction int) {
+	if len(m.changes) == 0 {
+		m.statusMsg = "No differences"
+		return
+	}
+	current := m.order[m.currentPair()]
+	var target *diff.Pair
+	if direction > 0 {
+		for _, p := range m.changes {
+			if m.order[p] > current {
+				target = p
+				break
+			}
+		}
+	} else {
+		for i := len(m.changes) - 1; i >= 0; i-- {
+			if m.order[m.changes[i]] < current {
+				target = m.changes[i]
+				break
+			}
+		}
+	}
+	if target == nil {
+		m.statusMsg = "No more differences"
+		return
+	}
+	collapsed := false
+	for p := target.Parent; p != nil; p = p.Parent {
+		collapsed = collapsed || !p.Expanded
+		p.Expanded = true
+	}
+	if collapsed {
+		m.flatPairs = flattenPairs(m.root)
+		m.lines, m.lineIndex = m.buildLines()
+	}
+	m.setCursorToPair(target)
+	m.statusMsg = ""
+	m.show()
+}
+
+func (m *DiffModel) expandAll(p *diff.Pair, expanded bool) {
+	p.Expanded = expanded || p.Parent == nil
+	for _, child := range p.Children {
+		m.expandAll(child, expanded)
+	}
+}
+
+func (m DiffModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m DiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch typed := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = typed.Width
+		m.height = typed.Height
+		available := typed.Height - 2
+		if available < 1 {
+			available = 1
+		}
+		m.viewport.Width = typed.Width
+		m.viewport.Height = available
+		m.rebuild()
+		return m, nil
+	case tea.KeyMsg:
+		key := typed.String()
+		if m.pending != "" {
+			pending := m.pending
+			m.pending = ""
+			if key == "c" {
+				if pending == "]" {
+					m.jumpChange(1)
+				} else {
+					m.jumpChange(-1)
+				}
+				return m, nil
+			}
+		}
+		if key == "]" || key == "[" {
+			m.pending = key
+			return m, nil
+		}
+		switch m.keys.action(key) {
+		case "quit":
+			return m, tea.Quit
+		case "next_change":
+			m.jumpChange(1)
+		case "prev_change":
+			m.jumpChange(-1)
+		case "up":
+			m.moveCursor(-1)
+			m.show()
+		case "down":
+			m.moveCursor(1)
+			m.show()
+		case "page_up":
+			m.moveCursor(-m.pageSize())
+			m.show()
+		case "page_down":
+			m.moveCursor(m.pageSize())
+			m.show()
+		case "collapse":
+			p := m.currentPair()
+			if p.IsContainer() && p.Expanded {
+				p.Expanded = false
+				m.rebuild()
+			} else if p.Parent != nil {
+				m.setCursorToPair(p.Parent)
+				m.show()
+			}
+		case "expand":
+			if p := m.currentPair(); p.IsContainer() && !p.Expanded {
+				p.Expanded = true
+				m.rebuild()
+			}
+		case "toggle":
+			if p := m.currentPair(); p.IsContainer() {
+				p.Expanded = !p.Expanded
+				m.rebuild()
+			}
+		case "expand_all":
+			m.expandAll(m.root, true)
+			m.rebuild()
+		case "collapse_all":
+			m.expandAll(m.root, false)
+			m.rebuild()
+		case "top":
+			m.cursor = 0
+			m.show()
+		case "bottom":
+			m.cursor = len(m.flatPairs) - 1
+			m.show()
+		case "help":
+			m.helpMode = !m.helpMode
+		}
+	}
+	return m, nil
+}
+
+func (m DiffModel) pageSize() int {
+	if m.viewport.Height > 0 {
+		return m.viewport.Height
+	}
+	return 10
+}
+
+func (m DiffModel) View() string {
+	header := m.renderHeader()
+	footer := m.renderFooter()
+	if m.helpMode {
+		return header + "\n" + m.renderHelp() + "\n" + footer
+	}
+	return header + "\n" + m.viewport.View() + "\n" + footer
+}
+
+func (m DiffModel) renderHeader() string {
+	added, removed, changed := 0, 0, 0
+	for _, p := range m.changes {
+		switch p.Kind {
+		case diff.Added:
+			added++
+		case diff.Removed:
+			removed++
+		default:
+			changed++
+		}
+	}
+	left := "jv diff - " + m.oldName + " <> " + m.newName
+	counts := fmt.Sprintf("+%d -%d ~%d", added, removed, changed)
+	right := counts + "  [?] Help  [q] Quit"
+	return m.styles.Header.Render(joinWithGap(left, right, m.width))
+}
+
+func (m DiffModel) renderFooter() string {
+	p := m.currentPair()
+	footer := "Path: " + p.Path()
+	for i, c := range m.changes {
+		if c == p {
+			footer += fmt.Sprintf("  Change: %d/%d (%s)", i+1, len(m.changes), c.Kind)
+			break
+		}
+	}
+	if m.statusMsg != "" {
+		footer += "  " + m.statusMsg
+	}
+	return m.styles.Footer.Render(footer)
+}
+
+func (m DiffModel) renderHelp() string {
+	lines := []string{"Keys:"}
+	lines = append(lines, m.keys.helpLines("up", "down", "page_up", "page_down", "collapse", "expand", "toggle")...)
+	lines = append(lines, "  ]c / [c : Next / Previous difference")
+	lines = append(lines, m.keys.helpLines("next_change", "prev_change", "expand_all", "collapse_all", "top", "bottom", "help", "quit")...)
+	content := strings.Join(lines, "\n")
+	if m.width > 0 {
+		content = lipgloss.NewStyle().Width(m.width).Render(content)
+	}
+	return m.styles.Help.Render(content)
+}
+
+// diffSide selects the old (left) or new (right) pane.
+type diffSide int
+
+const (
+	sideOld diffSide = iota
+	sideNew
+)
+
+func (s diffSide) node(p *diff.Pair) *parser.Node {
+	if s == sideOld {
+		return p.Old
+	}
+	return p.New
+}
+
+func (m DiffModel) buildLines() ([]string, map[*diff.Pair]int) {
+	lines := []string{}
+	lineIndex := map[*diff.Pair]int{}
+	m.renderPair(&lines, lineIndex, m.root, [2]string{})
+	return lines, lineIndex
+}
+
+// renderPair adds the rows of p. commas holds what follows p on the old
+// and the new side: a comma when a later sibling is present on that side.
+func (m DiffModel) renderPair(lines *[]string, lineIndex map[*diff.Pair]int, p *diff.Pair, commas [2]string) {
+	lineIndex[p] = len(*lines)
+	if p.IsContainer() && p.Expanded {
+		m.addRow(lines, p, m.sideOpen(p, sideOld), m.sideOpen(p, sideNew))
+		last := [2]int{-1, -1}
+		for i, child := range p.Children {
+			for _, side := range []diffSide{sideOld, sideNew} {
+				if side.node(child) != nil {
+					last[side] = i
+				}
+			}
+		}
+		for i, child := range p.Children {
+			var childCommas [2]string
+			for _, side := range []diffSide{sideOld, sideNew} {
+				if i < last[side] {
+					childCommas[side] = ","
+				}
+			}
+			m.renderPair(lines, lineIndex, child, childCommas)
+		}
+		m.addRow(lines, nil, m.sideClose(p, sideOld, commas[sideOld]), m.sideClose(p, sideNew, commas[sideNew]))
+		return
+	}
+	m.addRow(lines, p, m.sideLeaf(p, sideOld, commas[sideOld]), m.sideLeaf(p, sideNew, commas[sideNew]))
+}
+
+func (m DiffModel) indent(p *diff.Pair) string {
+	return strings.Repeat(" ", m.tokens.Spacing.Indent*p.Depth)
+}
+
+func (m DiffModel) keyPrefix(p *diff.Pair, side diffSide) string {
+	if p.Parent == nil {
+		return ""
+	}
+	if parent := side.node(p.Parent); parent != nil && parent.Type == parser.TypeObject {
+		return strconv.Quote(p.Key) + ": "
+	}
+	return ""
+}
+
+func (m DiffModel) sideOpen(p *diff.Pair, side diffSide) string {
+	node := side.node(p)
+	open := "{"
+	if node.Type == parser.TypeArray {
+		open = "["
+	}
+	return m.indent(p) + m.keyPrefix(p, side) + open
+}
+
+func (m DiffModel) sideClose(p *diff.Pair, side diffSide, comma string) string {
+	node := side.node(p)
+	close := "}"
+	if node.Type == parser.TypeArray {
+		close = "]"
+	}
+	return m.indent(p) + close + comma
+}
+
+func (m DiffModel) sideLeaf(p *diff.Pair, side diffSide, comma string) string {
+	node := side.node(p)
+	if node == nil {
+		return ""
+	}
+	value := ""
+	switch {
+	case p.IsContainer():
+		value = "{...}"
+		if node.Type == parser.TypeArray {
+			value = "[...]"
+		}
+		if p.Changes() > 0 {
+			value += " " + m.styles.TypeHint.Render("~"+itoa(p.Changes()))
+		} else {
+			value += containerSummary(node, m.styles)
+		}
+	case p.Kind != "" && (node.Type == parser.TypeObject || node.Type == parser.TypeArray):
+		value = diff.Compact(node)
+	case p.Kind != "":
+		value = node.StringValue()
+	default:
+		value = formatNodeValue(node, m.styles)
+		if value == "" {
+			value = diff.Compact(node)
+		}
+	}
+	return m.indent(p) + m.keyPrefix(p, side) + value + comma
+}
+
+func (m DiffModel) addRow(lines *[]string, p *diff.Pair, left, right string) {
+	gutter := " "
+	leftStyle, rightStyle := lipgloss.NewStyle(), lipgloss.NewStyle()
+	if p != nil {
+		switch {
+		case p.Kind == diff.Added:
+			gutter = "+"
+			rightStyle = m.styles.Added
+		case p.Kind == diff.Removed:
+			gutter = "-"
+			leftStyle = m.styles.Removed
+		case p.Kind == diff.Changed:
+			gutter = "~"
+			leftStyle, rightStyle = m.styles.Removed, m.styles.Added
+		case p.Changes() > 0:
+			gutter = "~"
+			leftStyle, rightStyle = m.styles.Changed, m.styles.Changed
+		}
+	}
+
+	pane := (m.width - 3) / 2
+	if pane < 10 {
+		pane = 10
+	}
+	*lines = append(*lines, fitPane(leftStyle.Render(left), pane)+" "+m.styles.TypeHint.Render(gutter)+" "+fitPane(rightStyle.Render(right), pane))
+}
+
+func fitPane(s string, width int) string {
+	s = lipgloss.NewStyle().MaxWidth(width).Render(s)
+	if gap := width - lipgloss.Width(s); gap > 0 {
+		s += strings.Repeat(" ", gap)
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/simota/jv/internal/parser"
+)
+
+// followMsg carries the records appended since the last poll of
+// Options.Follow, and the error for lines that could not be parsed.
+type followMsg struct {
+	records []*parser.Node
+	err     error
+}
+
+// followNext polls for new records in the background.
+func (m Model) followNext() tea.Cmd {
+	if m.follow == nil {
+		return nil
+	}
+	follow := m.follow
+	return tea.Tick(m.watchInterval, func(time.Time) tea.Msg {
+		records, err := follow()
+		return followMsg{records: records, err: err}
+	})
+}
+
+// appendRecords adds records to the end of the document. The view keeps
+// scrolling with them while the cursor is on the last line; moving away
+// pauses it until the cursor returns to the bottom.
+func (m *Model) appendRecords(records []*parser.Node) {
+	atBottom := m.following()
+	for _, record := range records {
+		m.tree.Append(record)
+		applyExpandDepth(record, m.depth)
+	}
+	m.collapseRecords(records)
+	if m.extents != nil {
+		m.extents = parser.Measure(m.tree)
+	}
+	m.rebuild()
+	if atBottom {
+		m.cursor = len(m.flatNodes) - 1
+		m.rebuild()
+	}
+}
+
+func (m Model) following() bool {
+	return m.follow != nil && m.cursor == len(m.flatNodes)-1
+}
@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// scope is the set of models an action is bound in. Keys only need to be
+// unique within one model, so n can jump between differences in jv diff
+// -i and be bound to something else in the viewer.
+type scope int
+
+const (
+	viewerScope scope = 1 << iota
+	diffScope
+	allScopes = viewerScope | diffScope
+)
+
+type action struct {
+	name  string
+	help  string
+	scope scope
+}
+
+// actions are the rebindable commands, in the order shown in the help.
+var actions = []action{
+	{"up", "Move up", allScopes},
+	{"down", "Move down", allScopes},
+	{"page_up", "Page up", allScopes},
+	{"page_down", "Page down", allScopes},
+	{"collapse", "Collapse / go to parent", allScopes},
+	{"expand", "Expand", allScopes},
+	{"toggle", "Toggle", allScopes},
+	{"expand_all", "Open all", allScopes},
+	{"collapse_all", "Close all", allScopes},
+	{"top", "Top", allScopes},
+	{"bottom", "Bottom", allScopes},
+	{"search", "Search", viewerScope},
+	{"types", "Toggle type hints", viewerScope},
+	{"copy", "Copy value", viewerScope},
+	{"stats", "Toggle size panel", viewerScope},
+	{"next_file", "Next file", viewerScope},
+	{"prev_file", "Previous file", viewerScope},
+	{"level_up", "Hide the lowest shown log level", viewerScope},
+	{"level_down", "Show one more log level", viewerScope},
+	{"refetch", "Fetch URLs again", viewerScope},
+	{"next_change", "Next difference", diffScope},
+	{"prev_change", "Previous difference", diffScope},
+	{"help", "Toggle help", allScopes},
+	{"quit", "Quit", allScopes},
+}
+
+var defaultKeys = map[string][]string{
+	"up":           {"up", "k"},
+	"down":         {"down", "j"},
+	"page_up":      {"pgup", "ctrl+u"},
+	"page_down":    {"pgdown", "ctrl+d"},
+	"collapse":     {"left", "h"},
+	"expand":       {"right", "l"},
+	"toggle":       {"enter", "space"},
+	"expand_all":   {"o"},
+	"collapse_all": {"O"},
+	"top":          {"g"},
+	"bottom":       {"G"},
+	"search":       {"/"},
+	"types":        {"t"},
+	"copy":         {"y"},
+	"stats":        {"s"},
+	"next_file":    {"tab"},
+	"prev_file":    {"shift+tab"},
+	"level_up":     {"+"},
+	"level_down":   {"-"},
+	"refetch":      {"r"},
+	"next_change":  {"n"},
+	"prev_change":  {"N"},
+	"help":         {"?"},
+	"quit":         {"q"},
+}
+
+// ActionNames lists the actions that can be rebound with Options.Keys.
+func ActionNames() []string {
+	names := make([]string, 0, len(actions))
+	for _, a := range actions {
+		names = append(names, a.name)
+	}
+	return names
+}
+
+// DefaultKeys returns the default bindings of action.
+func DefaultKeys(action string) []string {
+	return append([]string{}, defaultKeys[action]...)
+}
+
+// CheckKeys reports bindings for unknown actions and keys that would be
+// bound to two actions of the same model once keys replaces the defaults.
+func CheckKeys(keys map[string][]string) error {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := defaultKeys[name]; !ok {
+			return fmt.Errorf("unknown key action %q (expected one of %s)", name, strings.Join(ActionNames(), ", "))
+		}
+	}
+	for _, s := range []scope{viewerScope, diffScope} {
+		bound := map[string]string{"ctrl+c": "quit"}
+		for _, a := range actions {
+			if a.scope&s == 0 {
+				continue
+			}
+			for _, key := range bindings(a.name, keys) {
+				other, ok := bound[normalizeKey(key)]
+				if ok && other != a.name {
+					return fmt.Errorf("key %q is bound to both %s and %s", key, other, a.name)
+				}
+				bound[normalizeKey(key)] = a.name
+			}
+		}
+	}
+	return nil
+}
+
+// bindings returns the keys of action: those in overrides when it is
+// listed there, otherwise the defaults.
+func bindings(action string, overrides map[string][]string) []string {
+	if custom, ok := overrides[action]; ok {
+		return custom
+	}
+	return defaultKeys[action]
+}
+
+// keyMap resolves key presses, as reported by tea.KeyMsg.String, to
+// actions. ctrl+c always quits.
+type keyMap struct {
+	actions  map[string]string
+	bindings map[string][]string
+}
+
+// newKeyMap binds the actions of scope s. It starts from the defaults; an
+// action listed in overrides keeps only the keys given there.
+func newKeyMap(overrides map[string][]string, s scope) keyMap {
+	km := keyMap{actions: map[string]string{"ctrl+c": "quit"}, bindings: map[string][]string{}}
+	for _, a := range actions {
+		if a.scope&s == 0 {
+			continue
+		}
+		keys := bindings(a.name, overrides)
+		km.bindings[a.name] = keys
+		for _, key := range keys {
+			km.actions[normalizeKey(key)] = a.name
+		}
+	}
+	return km
+}
+
+func normalizeKey(key string) string {
+	if key == "space" {
+		return " "
+	}
+	return key
+}
+
+func (km keyMap) action(key string) string {
+	return km.actions[key]
+}
+
+// helpLines describes the given actions with their current bindings.
+func (km keyMap) helpLines(names ...string) []string {
+	lines := []string{}
+	for _, a := range actions {
+		for _, name := range names {
+			if a.name == name && len(km.bindings[name]) > 0 {
+				lines = append(lines, "  "+strings.Join(km.bindings[name], " / ")+" : "+a.help)
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/simota/jv/internal/logs"
+	"github.com/simota/jv/internal/parser"
+)
+
+// isRecord reports whether node is a log record: an element of the root
+// array while the log view is on.
+func (m Model) isRecord(node *parser.Node) bool {
+	return m.log != nil && node.Parent != nil && node.Parent.Parent == nil && node.Parent.Type == parser.TypeArray
+}
+
+// record returns the summary of a log record, computed once per node.
+func (m Model) record(node *parser.Node) logs.Record {
+	r, ok := m.records[node]
+	if !ok {
+		r = logs.Summarize(node, *m.log)
+		m.records[node] = r
+	}
+	return r
+}
+
+// hidden reports whether node is a record below the level filter.
+func (m Model) hidden(node *parser.Node) bool {
+	return m.isRecord(node) && !logs.Visible(m.record(node).Level, m.minLevel)
+}
+
+// flatten lists the visible nodes of root, leaving out the records hidden
+// by the level filter.
+func (m Model) flatten(root *parser.Node) []*parser.Node {
+	if m.log == nil || !root.Expanded {
+		return flattenVisible(root)
+	}
+	out := []*parser.Node{root}
+	for _, child := range root.Children {
+		if !m.hidden(child) {
+			out = append(out, flattenVisible(child)...)
+		}
+	}
+	return out
+}
+
+// collapseRecords starts records closed, one summary line each, whatever
+// the initial expand depth.
+func (m Model) collapseRecords(records []*parser.Node) {
+	for _, record := range records {
+		if m.isRecord(record) {
+			record.Expanded = false
+		}
+	}
+}
+
+// shiftLevel moves the level filter by delta, keeping the cursor on the
+// selected node when it stays visible. The footer shows the new filter.
+func (m *Model) shiftLevel(delta int) {
+	level := m.minLevel + delta
+	if level < 0 || level >= len(logs.Levels) {
+		return
+	}
+	selected := m.currentNode()
+	m.minLevel = level
+	m.rebuild()
+	m.setCursorToNode(selected)
+	m.rebuild()
+}
+
+func (m Model) levelFilter() string {
+	if m.minLevel <= 0 {
+		return "all"
+	}
+	return logs.Levels[m.minLevel] + "+"
+}
+
+// recordSummary renders a record as `TIME LEVEL message`, the level
+// colored by severity.
+func (m Model) recordSummary(node *parser.Node) string {
+	r := m.record(node)
+	parts := []string{}
+	if r.Time != "" {
+		parts = append(parts, m.styles.TypeHint.Render(r.Time))
+	}
+	style := m.styles.TypeHint
+	switch r.Level {
+	case "error", "fatal":
+		style = m.styles.Removed
+	case "warn":
+		style = m.styles.Changed
+	case "info":
+		style = m.styles.Added
+	}
+	parts = append(parts, style.Render(r.Label()))
+	if r.Message != "" {
+		parts = append(parts, r.Message)
+	}
+	return strings.Join(parts, " ")
+}
+
+// renderRecord shows a record as its summary line. Expanded, the full
+// record follows it.
+func (m Model) renderRecord(lines *[]string, lineIndex map[*parser.Node]int, node *parser.Node, depth int) {
+	if m.hidden(node) {
+		return
+	}
+	indent := strings.Repeat(m.indentUnit(), depth)
+	line := indent + m.recordSummary(node)
+	if !node.Expanded || len(node.Children) == 0 {
+		m.addLine(lines, lineIndex, node, m.attachTypeHint(line, node))
+		return
+	}
+	m.addLine(lines, lineIndex, node, m.attachTypeHint(line+" "+m.containerOpen(node), node))
+	for i, child := range node.Children {
+		m.renderJSON(lines, lineIndex, child, depth+1, i == len(node.Children)-1)
+	}
+	m.addLine(lines, lineIndex, nil, indent+m.containerClose(node))
+}
+
+// shownRecords counts the records that pass the level filter.
+func (m Model) shownRecords() int {
+	n := 0
+	for _, child := range m.tree.Children {
+		if !m.hidden(child) {
+			n++
+		}
+	}
+	return n
+}
@@ -2,23 +2,70 @@ package tui
 
 import (
 	"encoding/json"
-	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/simota/jv/internal/logs"
 	"github.com/simota/jv/internal/parser"
+	"github.com/simota/jv/internal/theme"
 )
 
 type Options struct {
 	Depth        int
 	Theme        string
 	ColorEnabled bool
-	ShowTypes    bool
+	// Profile is the color depth used for the theme's colors.
+	Profile   theme.Profile
+	ShowTypes bool
+	// ShowStats opens the size panel on start.
+	ShowStats bool
+	// Keys rebinds actions, see ActionNames.
+	Keys map[string][]string
+	// Reload is polled every WatchInterval for new contents of the
+	// documents and returns nil when nothing changed.
+	Reload func() ([]Document, error)
+	// Follow is polled every WatchInterval for records appended to the
+	// document, which must be an array.
+	Follow        func() ([]*parser.Node, error)
+	WatchInterval time.Duration
+	// Refetch loads the documents again when the refetch key is pressed.
+	Refetch func() ([]Document, error)
+	// Log shows the elements of a root array as log records, one summary
+	// line each, found with these fields. LogLevel is the initial level
+	// filter, an index into logs.Levels.
+	Log      *logs.Fields
+	LogLevel int
+}
+
+// Document is one file shown in the viewer.
+type Document struct {
+	// Name labels the document in the file tabs shown when there are
+	// several.
+	Name string
+	Root *parser.Node
+	// Invalid maps nodes that failed schema validation to their messages.
+	Invalid map[*parser.Node]string
+	// Response holds the status, statusText and headers of a document
+	// fetched over HTTP, shown as a collapsible node above it.
+	Response *parser.Node
+}
+
+// file is an open document with the position to return to when switching
+// back to it. Expansion state lives in the tree itself.
+type file struct {
+	Document
+	cursor        int
+	yOffset       int
+	invalidWithin map[*parser.Node]bool
+	extents       map[*parser.Node]parser.Extent
 }
 
 type Model struct {
+	files      []file
+	current    int
 	tree       *parser.Node
 	flatNodes  []*parser.Node
 	cursor     int
@@ -34,12 +81,43 @@ type Model struct {
 	searchMode bool
 	helpMode   bool
 	search     textinput.Model
+	invalid    map[*parser.Node]string
+	// invalidWithin marks containers with an invalid descendant so they
+	// stay highlighted while collapsed.
+	invalidWithin map[*parser.Node]bool
+	showStats     bool
+	keys          keyMap
+	// extents is computed the first time the size panel is shown.
+	extents map[*parser.Node]parser.Extent
+	// depth is the initial expand depth, also used for nodes that appear
+	// in a reload.
+	depth         int
+	reload        func() ([]Document, error)
+	follow        func() ([]*parser.Node, error)
+	refetch       func() ([]Document, error)
+	watchInterval time.Duration
+	response      *parser.Node
+	// flash marks the nodes changed by the last reload; flashWithin marks
+	// their ancestors so that collapsed containers show the change.
+	flash       map[*parser.Node]bool
+	flashWithin map[*parser.Node]bool
+	flashGen    int
+	// log is set in the log view; records caches the record summaries
+	// and minLevel is the level filter.
+	log      *logs.Fields
+	records  map[*parser.Node]logs.Record
+	minLevel int
 }
 
-func NewModel(root *parser.Node, opts Options) Model {
-	tokens := DefaultTokens(opts.Theme, opts.ColorEnabled)
+func NewModel(docs []Document, opts Options) Model {
+	tokens := DefaultTokens(opts.Theme, opts.Profile, opts.ColorEnabled)
 	styles := NewStyles(tokens)
-	applyExpandDepth(root, opts.Depth)
+	files := make([]file, 0, len(docs))
+	for _, doc := range docs {
+		applyExpandDepth(doc.Root, opts.Depth)
+		initResponse(doc.Response, opts.Depth)
+		files = append(files, file{Document: doc, invalidWithin: invalidAncestors(doc.Invalid)})
+	}
 
 	vp := viewport.New(0, 0)
 	search := textinput.New()
@@ -48,20 +126,96 @@ func NewModel(root *parser.Node, opts Options) Model {
 	search.Width = 30
 
 	m := Model{
-		tree:      root,
-		styles:    styles,
-		tokens:    tokens,
-		showTypes: opts.ShowTypes,
-		viewport:  vp,
-		search:    search,
-		statusMsg: "",
+		files:         files,
+		styles:        styles,
+		tokens:        tokens,
+		showTypes:     opts.ShowTypes,
+		viewport:      vp,
+		search:        search,
+		statusMsg:     "",
+		keys:          newKeyMap(opts.Keys, viewerScope),
+		depth:         opts.Depth,
+		reload:        opts.Reload,
+		follow:        opts.Follow,
+		refetch:       opts.Refetch,
+		watchInterval: opts.WatchInterval,
+		log:           opts.Log,
+		records:       map[*parser.Node]logs.Record{},
+		minLevel:      opts.LogLevel,
+	}
+	for _, f := range files {
+		m.collapseRecords(f.Root.Children)
+	}
+	m.load()
+	if opts.ShowStats {
+		m.toggleStats()
 	}
 	m.rebuild()
+	if m.follow != nil {
+		m.cursor = len(m.flatNodes) - 1
+		m.rebuild()
+	}
 	return m
 }
 
+func invalidAncestors(invalid map[*parser.Node]string) map[*parser.Node]bool {
+	within := map[*parser.Node]bool{}
+	for node := range invalid {
+		for p := node.Parent; p != nil; p = p.Parent {
+			within[p] = true
+		}
+	}
+	return within
+}
+
+// switchFile shows the document delta places away in the file tabs.
+func (m *Model) switchFile(delta int) {
+	if len(m.files) < 2 {
+		return
+	}
+	m.save()
+	m.current = (m.current + delta + len(m.files)) % len(m.files)
+	m.load()
+	m.statusMsg = "File: " + m.files[m.current].Name
+}
+
+// save records the position in the current file.
+func (m *Model) save() {
+	f := &m.files[m.current]
+	f.cursor, f.yOffset, f.extents = m.cursor, m.viewport.YOffset, m.extents
+}
+
+// load makes the current file the one shown.
+func (m *Model) load() {
+	f := m.files[m.current]
+	m.tree, m.response, m.invalid, m.invalidWithin = f.Root, f.Response, f.Invalid, f.invalidWithin
+	m.cursor, m.extents = f.cursor, f.extents
+	if m.showStats && m.extents == nil {
+		m.extents = parser.Measure(m.tree)
+	}
+	m.viewport.YOffset = f.yOffset
+}
+
+func (m *Model) toggleStats() {
+	m.showStats = !m.showStats
+	if m.showStats && m.extents == nil {
+		m.extents = parser.Measure(m.tree)
+	}
+	m.layout()
+}
+
+// layout sizes the viewport to the window, leaving room for the size
+// panel when it is shown.
+func (m *Model) layout() {
+	width := m.width
+	if m.showStats && width > statsPanelWidth*2 {
+		width -= statsPanelWidth
+	}
+	m.viewport.Width = width
+}
+
 func (m *Model) rebuild() {
-	m.flatNodes = flattenVisible(m.tree)
+	m.flatNodes = m.flattenDocument(m.tree, m.response)
 	if len(m.flatNodes) == 0 {
 		m.flatNodes = []*parser.Node{m.tree}
 	}
@@ -96,16 +250,19 @@ func (m *Model) ensureCursorVisible() {
 }
 
 func applyExpandDepth(node *parser.Node, depth int) {
-	if depth <= 0 {
-		node.Expanded = node.Parent == nil
-	} else {
-		node.Expanded = node.Depth < depth
-	}
+	node.Expanded = defaultExpanded(node, depth)
 	for _, child := range node.Children {
 		applyExpandDepth(child, depth)
 	}
 }
 
+func defaultExpanded(node *parser.Node, depth int) bool {
+	if depth <= 0 {
+		return node.Parent == nil
+	}
+	return node.Depth < depth
+}
+
 func flattenVisible(node *parser.Node) []*parser.Node {
 	out := []*parser.Node{node}
 	if !node.Expanded {
@@ -129,7 +286,7 @@ func (m *Model) currentNode() *parser.Node {
 
 func (m *Model) currentNodeJSON() (string, error) {
 	node := m.currentNode()
-	value := nodeToValue(node)
+	value := node.ToValue()
 	data, err := json.MarshalIndent(value, "", "  ")
 	if err != nil {
 		return "", err
@@ -137,43 +294,6 @@ func (m *Model) currentNodeJSON() (string, error) {
 	return string(data), nil
 }
 
-func nodeToValue(node *parser.Node) any {
-	switch node.Type {
-	case parser.TypeObject:
-		obj := map[string]any{}
-		for _, child := range node.Children {
-			obj[child.Key] = nodeToValue(child)
-		}
-		return obj
-	case parser.TypeArray:
-		arr := make([]any, 0, len(node.Children))
-		for _, child := range node.Children {
-			arr = append(arr, nodeToValue(child))
-		}
-		return arr
-	case parser.TypeString:
-		if v, ok := node.Value.(string); ok {
-			return v
-		}
-		return fmt.Sprintf("%v", node.Value)
-	case parser.TypeNumber:
-		return json.Number(node.StringValue())
-	case parser.TypeBoolean:
-		if v, ok := node.Value.(bool); ok {
-			return v
-		}
-		parsed, err := strconv.ParseBool(node.StringValue())
-		if err == nil {
-			return parsed
-		}
-		return node.StringValue()
-	case parser.TypeNull:
-		return nil
-	default:
-		return node.StringValue()
-	}
-}
-
 func (m *Model) moveCursor(delta int) {
 	if len(m.flatNodes) == 0 {
 		return
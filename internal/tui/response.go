@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/simota/jv/internal/parser"
+)
+
+// initResponse starts the response of a fetched document collapsed to its
+// status line.
+func initResponse(response *parser.Node, depth int) {
+	if response == nil {
+		return
+	}
+	applyExpandDepth(response, depth)
+	response.Expanded = false
+}
+
+// flattenDocument lists the visible nodes of the response, if any, then
+// those of the document.
+func (m Model) flattenDocument(root, response *parser.Node) []*parser.Node {
+	out := []*parser.Node{}
+	if response != nil {
+		out = flattenVisible(response)
+	}
+	return append(out, m.flatten(root)...)
+}
+
+// pathKey identifies a node across reloads. Nodes of the response are
+// told apart from those of the document, which have the same paths.
+func pathKey(doc Document, n *parser.Node) string {
+	if doc.Response != nil && rootOf(n) == doc.Response {
+		return "response" + strings.TrimPrefix(n.Path(), "$")
+	}
+	return n.Path()
+}
+
+func rootOf(n *parser.Node) *parser.Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// nodePath is the path shown in the footer.
+func (m Model) nodePath(n *parser.Node) string {
+	return pathKey(Document{Root: m.tree, Response: m.response}, n)
+}
+
+// refetchNow loads the documents again on request, as a reload.
+func (m Model) refetchNow() tea.Cmd {
+	refetch := m.refetch
+	return func() tea.Msg {
+		docs, err := refetch()
+		return reloadMsg{docs: docs, err: err}
+	}
+}
+
+// responseSummary renders the status line, colored by the class of the
+// status, followed by the request.
+func (m Model) responseSummary() string {
+	field := func(name string) string {
+		for _, child := range m.response.Children {
+			if child.Key == name && child.Value != nil {
+				if s, ok := child.Value.(string); ok {
+					return s
+				}
+			}
+		}
+		return ""
+	}
+	status, _ := strconv.Atoi(field("status"))
+	style := m.styles.Added
+	switch {
+	case status >= 400:
+		style = m.styles.Removed
+	case status >= 300:
+		style = m.styles.Changed
+	}
+	line := style.Render(strings.TrimSpace(field("proto") + " " + field("status") + " " + field("statusText")))
+	return line + " " + m.styles.TypeHint.Render(field("method")+" "+field("url"))
+}
+
+// renderResponse shows the response above the document as its status
+// line. Expanded, the status fields and headers follow it.
+func (m Model) renderResponse(lines *[]string, lineIndex map[*parser.Node]int) {
+	node := m.response
+	if !node.Expanded {
+		m.addLine(lines, lineIndex, node, m.responseSummary()+containerSummary(node, m.styles))
+		return
+	}
+	m.addLine(lines, lineIndex, node, m.responseSummary()+" "+m.containerOpen(node))
+	for i, child := range node.Children {
+		m.renderJSON(lines, lineIndex, child, 1, i == len(node.Children)-1)
+	}
+	m.addLine(lines, lineIndex, nil, m.containerClose(node))
+}
@@ -1,13 +1,39 @@
 package tui
 
 import (
+	"fmt"
+	"os"
+	"runtime"
+
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/simota/jv/internal/parser"
+	"golang.org/x/term"
 )
 
-func Run(root *parser.Node, opts Options) error {
-	model := NewModel(root, opts)
-	program := tea.NewProgram(model, tea.WithAltScreen())
-	_, err := program.Run()
+// Run shows docs, with tabs to switch between them when there are several.
+func Run(docs []Document, opts Options) error {
+	return runProgram(NewModel(docs, opts))
+}
+
+// runProgram shows model on the alternate screen. When stdin is not a
+// terminal, as in `cat file.json | jv -i`, it has already been read for the
+// document, so keys are read from the controlling terminal instead.
+func runProgram(model tea.Model) error {
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		tty, err := openTTY()
+		if err != nil {
+			return fmt.Errorf("interactive mode needs a terminal for keyboard input, but stdin is not a terminal and there is no controlling terminal: %w", err)
+		}
+		defer tty.Close()
+		options = append(options, tea.WithInput(tty))
+	}
+	_, err := tea.NewProgram(model, options...).Run()
 	return err
 }
+
+func openTTY() (*os.File, error) {
+	if runtime.GOOS == "windows" {
+		return os.OpenFile("CONIN$", os.O_RDWR, 0)
+	}
+	return os.Open("/dev/tty")
+}
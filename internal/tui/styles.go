@@ -1,6 +1,9 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/simota/jv/internal/theme"
+)
 
 type Styles struct {
 	Key      lipgloss.Style
@@ -13,6 +16,10 @@ type Styles struct {
 	Header   lipgloss.Style
 	Footer   lipgloss.Style
 	Help     lipgloss.Style
+	Error    lipgloss.Style
+	Added    lipgloss.Style
+	Removed  lipgloss.Style
+	Changed  lipgloss.Style
 }
 
 func NewStyles(tokens Tokens) Styles {
@@ -28,6 +35,10 @@ func NewStyles(tokens Tokens) Styles {
 		Header:   base.Bold(tokens.Typography.HeaderBold),
 		Footer:   base,
 		Help:     base,
+		Error:    base.Underline(true),
+		Added:    base,
+		Removed:  base,
+		Changed:  base,
 	}
 
 	if tokens.Colors.Key == "" {
@@ -44,6 +55,41 @@ func NewStyles(tokens Tokens) Styles {
 	styles.Header = base.Bold(tokens.Typography.HeaderBold).Foreground(lipgloss.Color(tokens.Colors.Header))
 	styles.Footer = base.Foreground(lipgloss.Color(tokens.Colors.Footer))
 	styles.Help = base.Foreground(lipgloss.Color(tokens.Colors.Help))
+	styles.Error = base.Foreground(lipgloss.Color(tokens.Colors.Error)).Underline(true)
+	styles.Added = base.Foreground(lipgloss.Color(tokens.Colors.Added))
+	styles.Removed = base.Foreground(lipgloss.Color(tokens.Colors.Removed))
+	styles.Changed = base.Foreground(lipgloss.Color(tokens.Colors.Changed))
+
+	a := tokens.Attrs
+	styles.Key = withAttrs(styles.Key, a.Key)
+	styles.String = withAttrs(styles.String, a.String)
+	styles.Number = withAttrs(styles.Number, a.Number)
+	styles.Boolean = withAttrs(styles.Boolean, a.Boolean)
+	styles.Null = withAttrs(styles.Null, a.Null)
+	styles.TypeHint = withAttrs(styles.TypeHint, a.TypeHint)
+	styles.Selected = withAttrs(styles.Selected, a.Selected)
+	styles.Header = withAttrs(styles.Header, a.Header)
+	styles.Footer = withAttrs(styles.Footer, a.Footer)
+	styles.Help = withAttrs(styles.Help, a.Help)
+	styles.Error = withAttrs(styles.Error, a.Error)
+	styles.Added = withAttrs(styles.Added, a.Added)
+	styles.Removed = withAttrs(styles.Removed, a.Removed)
+	styles.Changed = withAttrs(styles.Changed, a.Changed)
 
 	return styles
 }
+
+// withAttrs adds the theme's text attributes; it never removes ones the
+// style already has, such as the bold header.
+func withAttrs(style lipgloss.Style, attrs theme.Attrs) lipgloss.Style {
+	if attrs.Bold {
+		style = style.Bold(true)
+	}
+	if attrs.Italic {
+		style = style.Italic(true)
+	}
+	if attrs.Underline {
+		style = style.Underline(true)
+	}
+	return style
+}
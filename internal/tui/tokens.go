@@ -1,7 +1,10 @@
 package tui
 
+import "github.com/simota/jv/internal/theme"
+
 type Tokens struct {
 	Colors     ColorTokens
+	Attrs      AttrTokens
 	Spacing    SpacingTokens
 	Typography TypographyTokens
 	Effects    EffectTokens
@@ -19,6 +22,28 @@ type ColorTokens struct {
 	Header     string
 	Footer     string
 	Help       string
+	Error      string
+	Added      string
+	Removed    string
+	Changed    string
+}
+
+// AttrTokens holds the bold/italic/underline attributes of each token.
+type AttrTokens struct {
+	Key      theme.Attrs
+	String   theme.Attrs
+	Number   theme.Attrs
+	Boolean  theme.Attrs
+	Null     theme.Attrs
+	TypeHint theme.Attrs
+	Selected theme.Attrs
+	Header   theme.Attrs
+	Footer   theme.Attrs
+	Help     theme.Attrs
+	Error    theme.Attrs
+	Added    theme.Attrs
+	Removed  theme.Attrs
+	Changed  theme.Attrs
 }
 
 type SpacingTokens struct {
@@ -36,29 +61,54 @@ type EffectTokens struct {
 	Shadow string
 }
 
-func DefaultTokens(theme string, colorEnabled bool) Tokens {
+// DefaultTokens derives the TUI colors and text attributes from the named theme at the given
+// color profile. Unknown names fall back to the dark theme.
+func DefaultTokens(name string, profile theme.Profile, colorEnabled bool) Tokens {
 	colors := ColorTokens{}
+	attrs := AttrTokens{}
 	if colorEnabled {
+		t, err := theme.Load(name)
+		if err != nil {
+			t = &theme.Dark
+		}
 		colors = ColorTokens{
-			Key:        "6",
-			String:     "2",
-			Number:     "3",
-			Boolean:    "5",
-			Null:       "8",
-			TypeHint:   "8",
-			SelectedBg: "4",
-			SelectedFg: "0",
-			Header:     "8",
-			Footer:     "8",
-			Help:       "8",
+			Key:        t.Key.Value(profile),
+			String:     t.String.Value(profile),
+			Number:     t.Number.Value(profile),
+			Boolean:    t.Boolean.Value(profile),
+			Null:       t.Null.Value(profile),
+			TypeHint:   t.TypeHint.Value(profile),
+			SelectedBg: t.SelectedBg.Value(profile),
+			SelectedFg: t.SelectedFg.Value(profile),
+			Header:     t.Header.Value(profile),
+			Footer:     t.Footer.Value(profile),
+			Help:       t.Help.Value(profile),
+			Error:      t.Error.Value(profile),
+			Added:      t.Added.Value(profile),
+			Removed:    t.Removed.Value(profile),
+			Changed:    t.Changed.Value(profile),
 		}
-		if theme == "light" {
-			colors.SelectedBg = "12"
+		attrs = AttrTokens{
+			Key:      t.Key.Attrs,
+			String:   t.String.Attrs,
+			Number:   t.Number.Attrs,
+			Boolean:  t.Boolean.Attrs,
+			Null:     t.Null.Attrs,
+			TypeHint: t.TypeHint.Attrs,
+			Selected: t.SelectedFg.Attrs,
+			Header:   t.Header.Attrs,
+			Footer:   t.Footer.Attrs,
+			Help:     t.Help.Attrs,
+			Error:    t.Error.Attrs,
+			Added:    t.Added.Attrs,
+			Removed:  t.Removed.Attrs,
+			Changed:  t.Changed.Attrs,
 		}
 	}
 
 	return Tokens{
 		Colors: colors,
+		Attrs:  attrs,
 		Spacing: SpacingTokens{
 			Indent:    2,
 			InlineGap: 1,
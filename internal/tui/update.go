@@ -2,13 +2,14 @@ package tui
 
 import (
 	"strings"
+	"time"
 
 	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.watch(), m.followNext())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -22,10 +23,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if available < 1 {
 			available = 1
 		}
-		m.viewport.Width = typed.Width
 		m.viewport.Height = available
+		m.layout()
 		m.rebuild()
 		return m, nil
+	case reloadMsg:
+		cmds := []tea.Cmd{m.watch()}
+		if typed.err != nil {
+			m.statusMsg = "Reload failed: " + typed.err.Error()
+		} else if typed.docs != nil {
+			cmds = append(cmds, m.applyReload(typed.docs))
+			m.statusMsg = "Reloaded " + time.Now().Format("15:04:05")
+			m.rebuild()
+		}
+		return m, tea.Batch(cmds...)
+	case followMsg:
+		if len(typed.records) > 0 {
+			m.appendRecords(typed.records)
+		}
+		if typed.err != nil {
+			m.statusMsg = "Follow: " + typed.err.Error()
+		}
+		return m, m.followNext()
+	case flashDoneMsg:
+		if typed.gen == m.flashGen {
+			m.flash, m.flashWithin = nil, nil
+			m.rebuild()
+		}
+		return m, nil
 	}
 
 	if m.searchMode {
@@ -60,22 +85,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	if key, ok := msg.(tea.KeyMsg); ok {
-		switch key.String() {
-		case "ctrl+c", "q":
+		switch m.keys.action(key.String()) {
+		case "quit":
 			return m, tea.Quit
-		case "up", "k":
+		case "up":
 			m.moveCursor(-1)
 			m.rebuild()
-		case "down", "j":
+		case "down":
 			m.moveCursor(1)
 			m.rebuild()
-		case "pgup", "ctrl+u":
+		case "page_up":
 			m.movePage(-1)
 			m.rebuild()
-		case "pgdown", "ctrl+d":
+		case "page_down":
 			m.movePage(1)
 			m.rebuild()
-		case "left", "h":
+		case "collapse":
 			node := m.currentNode()
 			if len(node.Children) > 0 && node.Expanded {
 				node.Expanded = false
@@ -86,31 +111,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.setCursorToNode(node.Parent)
 				m.rebuild()
 			}
-		case "right", "l":
+		case "expand":
 			node := m.currentNode()
 			if len(node.Children) > 0 && !node.Expanded {
 				node.Expanded = true
 				m.rebuild()
 			}
-		case " ", "enter":
+		case "toggle":
 			node := m.currentNode()
 			if len(node.Children) > 0 {
 				node.Expanded = !node.Expanded
 				m.rebuild()
 			}
-		case "o":
+		case "expand_all":
 			m.expandAll(m.tree, true)
 			m.rebuild()
-		case "O":
+		case "collapse_all":
 			m.expandAll(m.tree, false)
 			m.rebuild()
-		case "g":
+		case "top":
 			m.cursor = 0
 			m.rebuild()
-		case "G":
+		case "bottom":
 			m.cursor = len(m.flatNodes) - 1
 			m.rebuild()
-		case "y":
+		case "copy":
 			value, err := m.currentNodeJSON()
 			if err != nil {
 				m.statusMsg = "Copy failed"
@@ -121,7 +146,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			m.statusMsg = "Copied value"
-		case "t":
+		case "types":
 			m.showTypes = !m.showTypes
 			if m.showTypes {
 				m.statusMsg = "Types: on"
@@ -129,9 +154,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.statusMsg = "Types: off"
 			}
 			m.rebuild()
-		case "?":
+		case "stats":
+			m.toggleStats()
+			m.rebuild()
+		case "next_file":
+			m.switchFile(1)
+			m.rebuild()
+		case "prev_file":
+			m.switchFile(-1)
+			m.rebuild()
+		case "level_up":
+			if m.log != nil {
+				m.shiftLevel(1)
+			}
+		case "level_down":
+			if m.log != nil {
+				m.shiftLevel(-1)
+			}
+		case "refetch":
+			if m.refetch != nil {
+				m.statusMsg = "Fetching..."
+				return m, m.refetchNow()
+			}
+		case "help":
 			m.helpMode = !m.helpMode
-		case "/":
+		case "search":
 			m.searchMode = true
 			m.search.Focus()
 			m.search.SetValue("")
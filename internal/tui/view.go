@@ -19,50 +19,88 @@ func (m Model) View() string {
 	}
 
 	body := m.viewport.View()
+	if m.showStats && m.viewport.Width < m.width {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, body, m.renderStats())
+	}
 	return header + "\n" + body + "\n" + footer
 }
 
 func (m Model) renderHeader() string {
 	left := "jv - JSON Viewer"
 	right := "[?] Help  [q] Quit"
+	if len(m.files) > 1 {
+		left = m.renderTabs(m.width - lipgloss.Width(right) - 1)
+	}
 	header := joinWithGap(left, right, m.width)
 	return m.styles.Header.Render(header)
 }
 
+// renderTabs lists the open files with the current one in brackets, or
+// only the current one when they do not fit in width.
+func (m Model) renderTabs(width int) string {
+	names := make([]string, len(m.files))
+	for i, f := range m.files {
+		names[i] = f.Name
+		if i == m.current {
+			names[i] = "[" + f.Name + "]"
+		}
+	}
+	tabs := strings.Join(names, " ")
+	if m.width > 0 && lipgloss.Width(tabs) > width {
+		tabs = fmt.Sprintf("[%s] %d/%d", m.files[m.current].Name, m.current+1, len(m.files))
+	}
+	return tabs
+}
+
 func (m Model) renderFooter() string {
 	node := m.currentNode()
-	path := node.Path()
+	path := m.nodePath(node)
 	lines := fmt.Sprintf("Lines: %d/%d", m.cursor+1, len(m.flatNodes))
 	depth := fmt.Sprintf("Depth: %d", node.Depth)
 	left := "Path: " + path
 	mid := lines + "  " + depth
 	footer := left + "  " + mid
 
+	if len(m.invalid) > 0 {
+		footer = footer + "  Invalid: " + itoa(len(m.invalid))
+	}
+	if m.log != nil && m.tree.Type == parser.TypeArray {
+		footer += fmt.Sprintf("  Records: %d/%d  Level: %s", m.shownRecords(), len(m.tree.Children), m.levelFilter())
+	}
+	if m.follow != nil {
+		if m.following() {
+			footer += "  Following"
+		} else {
+			footer += "  Paused (" + strings.Join(m.keys.bindings["bottom"], "/") + " to follow)"
+		}
+	}
+
 	if m.searchMode {
 		footer = footer + "  Search: " + m.search.View()
 	} else if m.statusMsg != "" {
 		footer = footer + "  " + m.statusMsg
+	} else if msg, ok := m.invalid[node]; ok {
+		return m.styles.Footer.Render(footer+"  ") + m.styles.Error.Render(msg)
 	}
 
 	return m.styles.Footer.Render(footer)
 }
 
 func (m Model) renderHelp() string {
-	lines := []string{
-		"Keys:",
-		"  Up/Down or k/j : Move",
-		"  PgUp/PgDn or Ctrl+u/Ctrl+d : Page",
-		"  Left/Right or h/l : Collapse/Expand",
-		"  Enter/Space : Toggle",
-		"  o / O : Open all / Close all",
-		"  1-9 : Expand to depth",
-		"  g / G : Top / Bottom",
-		"  / : Search",
-		"  t : Toggle type hints",
-		"  y : Copy value",
-		"  ? : Toggle help",
-		"  q : Quit",
+	lines := []string{"Keys:"}
+	lines = append(lines, m.keys.helpLines("up", "down", "page_up", "page_down", "collapse", "expand", "toggle", "expand_all", "collapse_all")...)
+	lines = append(lines, "  1-9 : Expand to depth")
+	lines = append(lines, m.keys.helpLines("top", "bottom", "search", "types", "stats", "copy")...)
+	if len(m.files) > 1 {
+		lines = append(lines, m.keys.helpLines("next_file", "prev_file")...)
 	}
+	if m.log != nil {
+		lines = append(lines, m.keys.helpLines("level_up", "level_down")...)
+	}
+	if m.refetch != nil {
+		lines = append(lines, m.keys.helpLines("refetch")...)
+	}
+	lines = append(lines, m.keys.helpLines("help", "quit")...)
 	content := strings.Join(lines, "\n")
 	if m.width > 0 {
 		content = lipgloss.NewStyle().Width(m.width).Render(content)
@@ -70,9 +108,47 @@ func (m Model) renderHelp() string {
 	return m.styles.Help.Render(content)
 }
 
+// statsPanelWidth includes the panel's left border.
+const statsPanelWidth = 26
+
+// renderStats shows the serialized size and node count of the selected
+// subtree next to the tree.
+func (m Model) renderStats() string {
+	node := m.currentNode()
+	ext := m.extents[node]
+	total := m.extents[m.tree]
+	share := 0.0
+	if total.Bytes > 0 {
+		share = 100 * float64(ext.Bytes) / float64(total.Bytes)
+	}
+	rows := [][2]string{
+		{"Type", string(node.Type)},
+		{"Size", parser.FormatBytes(ext.Bytes)},
+		{"Bytes", strconv.Itoa(ext.Bytes)},
+		{"Share", strconv.FormatFloat(share, 'f', 1, 64) + "%"},
+		{"Nodes", strconv.Itoa(ext.Nodes)},
+	}
+	if node.Type == parser.TypeObject || node.Type == parser.TypeArray {
+		rows = append(rows, [2]string{"Children", strconv.Itoa(len(node.Children))})
+	}
+	lines := []string{m.styles.Header.Render("Selected subtree"), ""}
+	for _, row := range rows {
+		lines = append(lines, m.styles.Key.Render(fmt.Sprintf("%-9s", row[0]))+m.styles.Number.Render(row[1]))
+	}
+	return lipgloss.NewStyle().
+		Width(statsPanelWidth-1).
+		Height(m.viewport.Height).
+		PaddingLeft(1).
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		Render(strings.Join(lines, "\n"))
+}
+
 func (m Model) buildLines() ([]string, map[*parser.Node]int) {
 	lines := []string{}
 	lineIndex := map[*parser.Node]int{}
+	if m.response != nil {
+		m.renderResponse(&lines, lineIndex)
+	}
 	m.renderJSON(&lines, lineIndex, m.tree, 0, true)
 	if len(lines) == 0 {
 		return []string{""}, lineIndex
@@ -90,6 +166,10 @@ func (m Model) renderJSON(lines *[]string, lineIndex map[*parser.Node]int, node
 		m.renderRoot(lines, lineIndex, node, indent)
 		return
 	}
+	if m.isRecord(node) {
+		m.renderRecord(lines, lineIndex, node, depth)
+		return
+	}
 	prefix := ""
 	if node.Parent.Type == parser.TypeObject {
 		prefix = strconv.Quote(node.Key) + ": "
@@ -145,6 +225,10 @@ func (m Model) renderRoot(lines *[]string, lineIndex map[*parser.Node]int, node
 func (m Model) addLine(lines *[]string, lineIndex map[*parser.Node]int, node *parser.Node, line string) {
 	if node != nil && node == m.currentNode() {
 		line = m.styles.Selected.Render(line)
+	} else if node != nil && m.isInvalid(node) {
+		line = m.styles.Error.Render(line)
+	} else if node != nil && m.isFlashing(node) {
+		line = m.styles.Changed.Render(line)
 	}
 	idx := len(*lines)
 	*lines = append(*lines, line)
@@ -153,6 +237,13 @@ func (m Model) addLine(lines *[]string, lineIndex map[*parser.Node]int, node *pa
 	}
 }
 
+func (m Model) isInvalid(node *parser.Node) bool {
+	if _, ok := m.invalid[node]; ok {
+		return true
+	}
+	return !node.Expanded && m.invalidWithin[node]
+}
+
 func (m Model) attachTypeHint(line string, node *parser.Node) string {
 	if !m.showTypes {
 		return line
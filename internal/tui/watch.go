@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/simota/jv/internal/logs"
+	"github.com/simota/jv/internal/parser"
+)
+
+// flashDuration is how long values that changed in a reload stay
+// highlighted.
+const flashDuration = 2 * time.Second
+
+// reloadMsg carries the result of polling Options.Reload. docs is nil when
+// nothing changed.
+type reloadMsg struct {
+	docs []Document
+	err  error
+}
+
+// flashDoneMsg ends the highlight of reload number gen.
+type flashDoneMsg struct {
+	gen int
+}
+
+// watch polls for new contents in the background.
+func (m Model) watch() tea.Cmd {
+	if m.reload == nil {
+		return nil
+	}
+	reload := m.reload
+	return tea.Tick(m.watchInterval, func(time.Time) tea.Msg {
+		docs, err := reload()
+		return reloadMsg{docs: docs, err: err}
+	})
+}
+
+// applyReload replaces the documents. Expansion state, the cursor and the
+// scroll position carry over by node path, and values that changed are
+// highlighted for flashDuration.
+func (m *Model) applyReload(docs []Document) tea.Cmd {
+	m.save()
+	previous := map[string]file{}
+	for _, f := range m.files {
+		previous[f.Name] = f
+	}
+	currentName := m.files[m.current].Name
+
+	m.records = map[*parser.Node]logs.Record{}
+	m.flash = map[*parser.Node]bool{}
+	m.flashWithin = map[*parser.Node]bool{}
+	files := make([]file, 0, len(docs))
+	for i, doc := range docs {
+		f := file{Document: doc, invalidWithin: invalidAncestors(doc.Invalid)}
+		if prev, ok := previous[doc.Name]; ok {
+			f.cursor = m.carryOver(prev, doc)
+			f.yOffset = prev.yOffset
+		} else {
+			applyExpandDepth(doc.Root, m.depth)
+			initResponse(doc.Response, m.depth)
+			m.collapseRecords(doc.Root.Children)
+		}
+		if doc.Name == currentName {
+			m.current = i
+		}
+		files = append(files, f)
+	}
+	m.files = files
+	if m.current >= len(m.files) {
+		m.current = len(m.files) - 1
+	}
+	m.load()
+
+	m.flashGen++
+	gen := m.flashGen
+	return tea.Tick(flashDuration, func(time.Time) tea.Msg {
+		return flashDoneMsg{gen: gen}
+	})
+}
+
+// carryOver copies the expansion state of prev onto the nodes of doc with
+// the same paths, marks nodes that are new or whose value changed, and
+// returns the cursor on the node that was selected, or its closest
+// surviving ancestor.
+func (m *Model) carryOver(prev file, doc Document) int {
+	type state struct {
+		expanded bool
+		typ      parser.NodeType
+		value    string
+	}
+	before := map[string]state{}
+	walkDocument(prev.Document, func(n *parser.Node) {
+		before[pathKey(prev.Document, n)] = state{expanded: n.Expanded, typ: n.Type, value: n.StringValue()}
+	})
+
+	walkDocument(doc, func(n *parser.Node) {
+		s, ok := before[pathKey(doc, n)]
+		if ok {
+			n.Expanded = s.expanded
+		} else {
+			n.Expanded = defaultExpanded(n, m.depth) && !m.isRecord(n) && n != doc.Response
+		}
+		leaf := len(n.Children) == 0
+		if !ok || s.typ != n.Type || leaf && s.value != n.StringValue() {
+			m.flash[n] = true
+			for p := n.Parent; p != nil; p = p.Parent {
+				m.flashWithin[p] = true
+			}
+		}
+	})
+
+	selected := []string{}
+	if flat := m.flattenDocument(prev.Root, prev.Response); prev.cursor < len(flat) {
+		for n := flat[prev.cursor]; n != nil; n = n.Parent {
+			selected = append(selected, pathKey(prev.Document, n))
+		}
+	}
+	flat := m.flattenDocument(doc.Root, doc.Response)
+	for _, path := range selected {
+		for i, n := range flat {
+			if pathKey(doc, n) == path {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+func walkDocument(doc Document, fn func(*parser.Node)) {
+	if doc.Response != nil {
+		walk(doc.Response, fn)
+	}
+	walk(doc.Root, fn)
+}
+
+func walk(n *parser.Node, fn func(*parser.Node)) {
+	fn(n)
+	for _, child := range n.Children {
+		walk(child, fn)
+	}
+}
+
+func (m Model) isFlashing(node *parser.Node) bool {
+	return m.flash[node] || !node.Expanded && m.flashWithin[node]
+}
@@ -0,0 +1,801 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+// Violation is a single failed schema keyword.
+type Violation struct {
+	// Node is the offending instance value.
+	Node *parser.Node
+	// Keyword is the failing keyword, e.g. "required" or "minimum".
+	Keyword string
+	// SchemaPath is the JSON Pointer of the keyword within the schema.
+	SchemaPath string
+	Message    string
+}
+
+func (v Violation) Error() string {
+	return v.Node.Path() + ": " + v.Keyword + ": " + v.Message
+}
+
+// Schema is a compiled JSON Schema (draft 2020-12). Supported keywords are
+// the applicator, validation, unevaluated and reference keywords. A $ref
+// is resolved against the $id of the schema resource it appears in, and
+// may point to a JSON Pointer, an anchor or another resource of the same
+// document; format is treated as an annotation, as the draft specifies by
+// default. Compile rejects $dynamicRef and references it cannot resolve.
+type Schema struct {
+	root any
+	// refs maps each schema holding a $ref, by schemaKey, to its target.
+	refs    map[uintptr]any
+	regexps map[string]*regexp.Regexp
+}
+
+// unsupportedKeywords would change the result if ignored.
+var unsupportedKeywords = []string{"$dynamicRef", "$recursiveRef"}
+
+func Compile(r io.Reader) (*Schema, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var root any
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	switch root.(type) {
+	case map[string]any, bool:
+	default:
+		return nil, errors.New("invalid schema: must be an object or boolean")
+	}
+	s := &Schema{root: root, refs: map[uintptr]any{}, regexps: map[string]*regexp.Regexp{}}
+	c := &compiler{
+		schema:    s,
+		resources: map[string]any{},
+		anchors:   map[string]any{},
+		bases:     map[uintptr]*url.URL{},
+	}
+	if err := c.compile(root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return s, nil
+}
+
+// schemaKey identifies a schema object, which is not comparable.
+func schemaKey(schema map[string]any) uintptr {
+	return reflect.ValueOf(schema).Pointer()
+}
+
+// compiler collects the schema resources of a document by their absolute
+// $id, and then resolves every $ref against the base URI in effect where
+// it appears.
+type compiler struct {
+	schema    *Schema
+	resources map[string]any
+	// anchors are keyed by resource URI and name, "uri#name".
+	anchors map[string]any
+	// bases holds the base URI of every schema object seen.
+	bases map[uintptr]*url.URL
+}
+
+func (c *compiler) compile(root any) error {
+	if err := c.collect(root, &url.URL{}); err != nil {
+		return err
+	}
+	// Reference targets outside the known keywords are collected when
+	// first resolved, so the list may grow while it is walked.
+	pending := []map[string]any{}
+	walkSchemas(root, func(schema map[string]any) {
+		pending = append(pending, schema)
+	})
+	for len(pending) > 0 {
+		schema := pending[0]
+		pending = pending[1:]
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			continue
+		}
+		target, base, err := c.resolve(ref, c.bases[schemaKey(schema)])
+		if err != nil {
+			return err
+		}
+		c.schema.refs[schemaKey(schema)] = target
+		if m, ok := target.(map[string]any); ok {
+			if _, seen := c.bases[schemaKey(m)]; !seen {
+				if err := c.collect(m, base); err != nil {
+					return err
+				}
+				walkSchemas(m, func(schema map[string]any) {
+					pending = append(pending, schema)
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// collect records the base URI of v and its subschemas, with the resources
+// and anchors they declare, and rejects unsupported keywords.
+func (c *compiler) collect(v any, base *url.URL) error {
+	var err error
+	walkSchemasWithBase(v, base, func(schema map[string]any, base *url.URL) {
+		c.bases[schemaKey(schema)] = base
+		for _, keyword := range unsupportedKeywords {
+			if _, ok := schema[keyword]; ok && err == nil {
+				err = fmt.Errorf("unsupported keyword %s", keyword)
+			}
+		}
+		if _, ok := schema["$id"].(string); ok {
+			c.resources[base.String()] = schema
+		}
+		if anchor, ok := schema["$anchor"].(string); ok {
+			c.anchors[base.String()+"#"+anchor] = schema
+		}
+	})
+	if _, ok := c.resources[base.String()]; !ok {
+		c.resources[base.String()] = v
+	}
+	return err
+}
+
+// resolve looks up ref relative to base. It returns the target and the
+// base URI in effect there.
+func (c *compiler) resolve(ref string, base *url.URL) (any, *url.URL, error) {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	abs := base.ResolveReference(parsed)
+	fragment := abs.Fragment
+	abs.Fragment, abs.RawFragment = "", ""
+	resource, ok := c.resources[abs.String()]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported remote reference %q", ref)
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		target, ok := c.anchors[abs.String()+"#"+fragment]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown anchor %q", ref)
+		}
+		return target, abs, nil
+	}
+	target, err := resolvePointer(resource, fragment)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return target, abs, nil
+}
+
+// Keywords whose values are a schema, a list of schemas or a map of
+// schemas by name.
+var (
+	schemaKeywords = []string{"items", "additionalProperties", "not", "if", "then", "else", "contains",
+		"propertyNames", "unevaluatedProperties", "unevaluatedItems"}
+	schemaListKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+	schemaMapKeywords  = []string{"properties", "patternProperties", "$defs", "definitions", "dependentSchemas"}
+)
+
+// walkSchemas calls fn for v and every subschema in it, skipping values
+// such as property names and const values that only look like schemas.
+func walkSchemas(v any, fn func(map[string]any)) {
+	walkSchemasWithBase(v, &url.URL{}, func(schema map[string]any, _ *url.URL) {
+		fn(schema)
+	})
+}
+
+// walkSchemasWithBase is walkSchemas, also passing the base URI of each
+// schema: base resolved against the $id of the schema and its ancestors.
+func walkSchemasWithBase(v any, base *url.URL, fn func(map[string]any, *url.URL)) {
+	schema, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	if id, ok := schema["$id"].(string); ok {
+		if parsed, err := url.Parse(id); err == nil {
+			base = base.ResolveReference(parsed)
+			base.Fragment, base.RawFragment = "", ""
+		}
+	}
+	fn(schema, base)
+	for _, keyword := range schemaKeywords {
+		walkSchemasWithBase(schema[keyword], base, fn)
+	}
+	for _, keyword := range schemaListKeywords {
+		list, _ := schema[keyword].([]any)
+		for _, sub := range list {
+			walkSchemasWithBase(sub, base, fn)
+		}
+	}
+	for _, keyword := range schemaMapKeywords {
+		subs, _ := schema[keyword].(map[string]any)
+		for _, name := range sortedKeys(subs) {
+			walkSchemasWithBase(subs[name], base, fn)
+		}
+	}
+}
+
+// Validate checks root against the schema and returns every violation,
+// in document order.
+func (s *Schema) Validate(root *parser.Node) []Violation {
+	v := &validator{schema: s}
+	v.validate(root, s.root, "#", 0)
+	return v.out
+}
+
+// maxRefDepth guards against reference cycles that never consume input.
+// It counts the references followed without moving to a child value, so
+// recursive schemas accept data of any depth.
+const maxRefDepth = 64
+
+type validator struct {
+	schema *Schema
+	out    []Violation
+}
+
+func (v *validator) fail(node *parser.Node, loc, keyword, format string, args ...any) {
+	v.out = append(v.out, Violation{
+		Node:       node,
+		Keyword:    keyword,
+		SchemaPath: loc + "/" + keyword,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// valid reports whether node satisfies schema without recording violations.
+func (v *validator) valid(node *parser.Node, schema any, loc string, refs int) bool {
+	sub := &validator{schema: v.schema}
+	sub.validate(node, schema, loc, refs)
+	return len(sub.out) == 0
+}
+
+func (v *validator) validate(node *parser.Node, schema any, loc string, refs int) {
+	switch typed := schema.(type) {
+	case bool:
+		if !typed {
+			v.out = append(v.out, Violation{Node: node, Keyword: "false", SchemaPath: loc, Message: "no value is allowed here"})
+		}
+		return
+	case map[string]any:
+		v.validateObject(node, typed, loc, refs)
+	}
+}
+
+func (v *validator) validateObject(node *parser.Node, schema map[string]any, loc string, refs int) {
+	if ref, ok := schema["$ref"].(string); ok {
+		if refs >= maxRefDepth {
+			v.fail(node, loc, "$ref", "reference depth exceeded at %s", ref)
+		} else {
+			v.validate(node, v.schema.refs[schemaKey(schema)], ref, refs+1)
+		}
+	}
+
+	v.checkType(node, schema, loc)
+	v.checkConst(node, schema, loc)
+	v.checkCombinators(node, schema, loc, refs)
+
+	switch node.Type {
+	case parser.TypeNumber:
+		v.checkNumber(node, schema, loc)
+	case parser.TypeString:
+		v.checkString(node, schema, loc)
+	case parser.TypeArray:
+		v.checkArray(node, schema, loc)
+		v.checkUnevaluatedItems(node, schema, loc, refs)
+	case parser.TypeObject:
+		v.checkProperties(node, schema, loc, refs)
+		v.checkUnevaluatedProperties(node, schema, loc, refs)
+	}
+}
+
+func (v *validator) checkType(node *parser.Node, schema map[string]any, loc string) {
+	raw, ok := schema["type"]
+	if !ok {
+		return
+	}
+	names := []string{}
+	switch typed := raw.(type) {
+	case string:
+		names = append(names, typed)
+	case []any:
+		for _, item := range typed {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		if matchesType(node, name) {
+			return
+		}
+	}
+	v.fail(node, loc, "type", "expected %s, got %s", strings.Join(names, " or "), instanceType(node))
+}
+
+func matchesType(node *parser.Node, name string) bool {
+	if name == "integer" {
+		return node.Type == parser.TypeNumber && isInteger(node.StringValue())
+	}
+	return string(node.Type) == name
+}
+
+func instanceType(node *parser.Node) string {
+	if node.Type == parser.TypeNumber && isInteger(node.StringValue()) {
+		return "integer"
+	}
+	return string(node.Type)
+}
+
+func isInteger(text string) bool {
+	r, ok := new(big.Rat).SetString(text)
+	return ok && r.IsInt()
+}
+
+func (v *validator) checkConst(node *parser.Node, schema map[string]any, loc string) {
+	if want, ok := schema["const"]; ok && !parser.EqualValues(node.ToValue(), want) {
+		v.fail(node, loc, "const", "expected %s", compactJSON(want))
+	}
+	if raw, ok := schema["enum"].([]any); ok {
+		for _, want := range raw {
+			if parser.EqualValues(node.ToValue(), want) {
+				return
+			}
+		}
+		options := make([]string, 0, len(raw))
+		for _, want := range raw {
+			options = append(options, compactJSON(want))
+		}
+		v.fail(node, loc, "enum", "%s is not one of %s", compactJSON(node.ToValue()), strings.Join(options, ", "))
+	}
+}
+
+func (v *validator) checkCombinators(node *parser.Node, schema map[string]any, loc string, refs int) {
+	if subs, ok := schema["allOf"].([]any); ok {
+		for i, sub := range subs {
+			v.validate(node, sub, loc+"/allOf/"+strconv.Itoa(i), refs)
+		}
+	}
+	if subs, ok := schema["anyOf"].([]any); ok {
+		matched := false
+		for i, sub := range subs {
+			if v.valid(node, sub, loc+"/anyOf/"+strconv.Itoa(i), refs) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(node, loc, "anyOf", "value does not match any of %d schemas", len(subs))
+		}
+	}
+	if subs, ok := schema["oneOf"].([]any); ok {
+		matches := 0
+		for i, sub := range subs {
+			if v.valid(node, sub, loc+"/oneOf/"+strconv.Itoa(i), refs) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(node, loc, "oneOf", "value matches %d of %d schemas, expected exactly 1", matches, len(subs))
+		}
+	}
+	if sub, ok := schema["not"]; ok && v.valid(node, sub, loc+"/not", refs) {
+		v.fail(node, loc, "not", "value must not match the schema")
+	}
+	if cond, ok := schema["if"]; ok {
+		if v.valid(node, cond, loc+"/if", refs) {
+			if then, ok := schema["then"]; ok {
+				v.validate(node, then, loc+"/then", refs)
+			}
+		} else if els, ok := schema["else"]; ok {
+			v.validate(node, els, loc+"/else", refs)
+		}
+	}
+}
+
+func (v *validator) checkNumber(node *parser.Node, schema map[string]any, loc string) {
+	value, ok := new(big.Rat).SetString(node.StringValue())
+	if !ok {
+		return
+	}
+	bound := func(keyword string, failed func(cmp int) bool, relation string) {
+		limit, ok := ratOf(schema[keyword])
+		if ok && failed(value.Cmp(limit)) {
+			v.fail(node, loc, keyword, "%s is %s %s", node.StringValue(), relation, limit.RatString())
+		}
+	}
+	bound("minimum", func(c int) bool { return c < 0 }, "less than")
+	bound("maximum", func(c int) bool { return c > 0 }, "greater than")
+	bound("exclusiveMinimum", func(c int) bool { return c <= 0 }, "not greater than")
+	bound("exclusiveMaximum", func(c int) bool { return c >= 0 }, "not less than")
+	if divisor, ok := ratOf(schema["multipleOf"]); ok && divisor.Sign() > 0 {
+		if !new(big.Rat).Quo(value, divisor).IsInt() {
+			v.fail(node, loc, "multipleOf", "%s is not a multiple of %s", node.StringValue(), divisor.RatString())
+		}
+	}
+}
+
+func (v *validator) checkString(node *parser.Node, schema map[string]any, loc string) {
+	text, _ := node.Value.(string)
+	length := utf8.RuneCountInString(text)
+	if limit, ok := intOf(schema["minLength"]); ok && length < limit {
+		v.fail(node, loc, "minLength", "length %d is less than %d", length, limit)
+	}
+	if limit, ok := intOf(schema["maxLength"]); ok && length > limit {
+		v.fail(node, loc, "maxLength", "length %d is greater than %d", length, limit)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := v.schema.regexp(pattern)
+		if err != nil {
+			v.fail(node, loc, "pattern", "invalid pattern %q: %v", pattern, err)
+		} else if !re.MatchString(text) {
+			v.fail(node, loc, "pattern", "%s does not match %q", node.StringValue(), pattern)
+		}
+	}
+}
+
+// checkArray validates the items of node. Moving to a child resets the
+// reference count.
+func (v *validator) checkArray(node *parser.Node, schema map[string]any, loc string) {
+	count := len(node.Children)
+	if limit, ok := intOf(schema["minItems"]); ok && count < limit {
+		v.fail(node, loc, "minItems", "%d items is less than %d", count, limit)
+	}
+	if limit, ok := intOf(schema["maxItems"]); ok && count > limit {
+		v.fail(node, loc, "maxItems", "%d items is greater than %d", count, limit)
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := 0; i < count; i++ {
+			for j := i + 1; j < count; j++ {
+				if parser.EqualValues(node.Children[i].ToValue(), node.Children[j].ToValue()) {
+					v.fail(node, loc, "uniqueItems", "items %d and %d are equal", i, j)
+					i, j = count, count
+				}
+			}
+		}
+	}
+
+	prefix := 0
+	if items, ok := schema["prefixItems"].([]any); ok {
+		for i, sub := range items {
+			if i >= count {
+				break
+			}
+			v.validate(node.Children[i], sub, loc+"/prefixItems/"+strconv.Itoa(i), 0)
+		}
+		prefix = len(items)
+	}
+	if items, ok := schema["items"]; ok {
+		for i := prefix; i < count; i++ {
+			v.validate(node.Children[i], items, loc+"/items", 0)
+		}
+	}
+
+	if contains, ok := schema["contains"]; ok {
+		matches := 0
+		for _, child := range node.Children {
+			if v.valid(child, contains, loc+"/contains", 0) {
+				matches++
+			}
+		}
+		min := 1
+		if limit, ok := intOf(schema["minContains"]); ok {
+			min = limit
+		}
+		if matches < min {
+			v.fail(node, loc, "contains", "%d items match, expected at least %d", matches, min)
+		}
+		if limit, ok := intOf(schema["maxContains"]); ok && matches > limit {
+			v.fail(node, loc, "maxContains", "%d items match, expected at most %d", matches, limit)
+		}
+	}
+}
+
+func (v *validator) checkProperties(node *parser.Node, schema map[string]any, loc string, refs int) {
+	count := len(node.Children)
+	if limit, ok := intOf(schema["minProperties"]); ok && count < limit {
+		v.fail(node, loc, "minProperties", "%d properties is less than %d", count, limit)
+	}
+	if limit, ok := intOf(schema["maxProperties"]); ok && count > limit {
+		v.fail(node, loc, "maxProperties", "%d properties is greater than %d", count, limit)
+	}
+
+	present := map[string]*parser.Node{}
+	for _, child := range node.Children {
+		present[child.Key] = child
+	}
+	if required, ok := schema["required"].([]any); ok {
+		missing := []string{}
+		for _, raw := range required {
+			if name, ok := raw.(string); ok && present[name] == nil {
+				missing = append(missing, strconv.Quote(name))
+			}
+		}
+		if len(missing) > 0 {
+			v.fail(node, loc, "required", "missing properties %s", strings.Join(missing, ", "))
+		}
+	}
+	if deps, ok := schema["dependentRequired"].(map[string]any); ok {
+		for _, name := range sortedKeys(deps) {
+			if present[name] == nil {
+				continue
+			}
+			list, _ := deps[name].([]any)
+			for _, raw := range list {
+				if dep, ok := raw.(string); ok && present[dep] == nil {
+					v.fail(node, loc, "dependentRequired", "%q requires %q", name, dep)
+				}
+			}
+		}
+	}
+
+	if deps, ok := schema["dependentSchemas"].(map[string]any); ok {
+		for _, name := range sortedKeys(deps) {
+			if present[name] != nil {
+				v.validate(node, deps[name], loc+"/dependentSchemas/"+parser.EscapePointer(name), refs)
+			}
+		}
+	}
+
+	// The properties are validated with a fresh reference count.
+	props, _ := schema["properties"].(map[string]any)
+	patterns, _ := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+	names, hasNames := schema["propertyNames"]
+	for _, child := range node.Children {
+		if hasNames {
+			keyNode := &parser.Node{Key: child.Key, Type: parser.TypeString, Value: child.Key, Parent: child.Parent, Depth: child.Depth}
+			if !v.valid(keyNode, names, loc+"/propertyNames", 0) {
+				v.fail(child, loc, "propertyNames", "property name %q is not allowed", child.Key)
+			}
+		}
+		matched := false
+		if sub, ok := props[child.Key]; ok {
+			matched = true
+			v.validate(child, sub, loc+"/properties/"+parser.EscapePointer(child.Key), 0)
+		}
+		for _, pattern := range sortedKeys(patterns) {
+			re, err := v.schema.regexp(pattern)
+			if err != nil || !re.MatchString(child.Key) {
+				continue
+			}
+			matched = true
+			v.validate(child, patterns[pattern], loc+"/patternProperties/"+parser.EscapePointer(pattern), 0)
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(child, loc, "additionalProperties", "property %q is not allowed", child.Key)
+				continue
+			}
+			v.validate(child, additional, loc+"/additionalProperties", 0)
+		}
+	}
+}
+
+func (v *validator) checkUnevaluatedProperties(node *parser.Node, schema map[string]any, loc string, refs int) {
+	unevaluated, ok := schema["unevaluatedProperties"]
+	if !ok {
+		return
+	}
+	seen := v.evaluated(node, schema, refs, false)
+	for _, child := range node.Children {
+		if seen.props[child.Key] {
+			continue
+		}
+		if allowed, ok := unevaluated.(bool); ok && !allowed {
+			v.fail(child, loc, "unevaluatedProperties", "property %q is not evaluated", child.Key)
+			continue
+		}
+		v.validate(child, unevaluated, loc+"/unevaluatedProperties", 0)
+	}
+}
+
+func (v *validator) checkUnevaluatedItems(node *parser.Node, schema map[string]any, loc string, refs int) {
+	unevaluated, ok := schema["unevaluatedItems"]
+	if !ok {
+		return
+	}
+	seen := v.evaluated(node, schema, refs, false)
+	for i, child := range node.Children {
+		if seen.items[i] {
+			continue
+		}
+		if allowed, ok := unevaluated.(bool); ok && !allowed {
+			v.fail(child, loc, "unevaluatedItems", "item %d is not evaluated", i)
+			continue
+		}
+		v.validate(child, unevaluated, loc+"/unevaluatedItems", 0)
+	}
+}
+
+// evaluation records the properties and items of an instance evaluated by
+// a schema and the subschemas it applies in place.
+type evaluation struct {
+	props map[string]bool
+	items map[int]bool
+}
+
+// evaluated collects the evaluation of node by schema. Only subschemas the
+// node satisfies contribute, and the unevaluated keywords of schema itself
+// count only when nested is set.
+func (v *validator) evaluated(node *parser.Node, schema any, refs int, nested bool) evaluation {
+	seen := evaluation{props: map[string]bool{}, items: map[int]bool{}}
+	v.collectEvaluated(node, schema, refs, nested, seen)
+	return seen
+}
+
+func (v *validator) collectEvaluated(node *parser.Node, schema any, refs int, nested bool, seen evaluation) {
+	m, ok := schema.(map[string]any)
+	if !ok {
+		return
+	}
+	if _, ok := m["$ref"].(string); ok && refs < maxRefDepth {
+		v.collectEvaluated(node, v.schema.refs[schemaKey(m)], refs+1, true, seen)
+	}
+	if subs, ok := m["allOf"].([]any); ok {
+		for _, sub := range subs {
+			v.collectEvaluated(node, sub, refs, true, seen)
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		subs, _ := m[keyword].([]any)
+		for _, sub := range subs {
+			if v.valid(node, sub, "", refs) {
+				v.collectEvaluated(node, sub, refs, true, seen)
+			}
+		}
+	}
+	if cond, ok := m["if"]; ok {
+		if v.valid(node, cond, "", refs) {
+			v.collectEvaluated(node, cond, refs, true, seen)
+			v.collectEvaluated(node, m["then"], refs, true, seen)
+		} else {
+			v.collectEvaluated(node, m["else"], refs, true, seen)
+		}
+	}
+
+	switch node.Type {
+	case parser.TypeObject:
+		if deps, ok := m["dependentSchemas"].(map[string]any); ok {
+			for _, child := range node.Children {
+				if sub, ok := deps[child.Key]; ok {
+					v.collectEvaluated(node, sub, refs, true, seen)
+				}
+			}
+		}
+		props, _ := m["properties"].(map[string]any)
+		patterns, _ := m["patternProperties"].(map[string]any)
+		_, additional := m["additionalProperties"]
+		_, unevaluated := m["unevaluatedProperties"]
+		for _, child := range node.Children {
+			matched := false
+			if _, ok := props[child.Key]; ok {
+				matched = true
+			}
+			for pattern := range patterns {
+				if re, err := v.schema.regexp(pattern); err == nil && re.MatchString(child.Key) {
+					matched = true
+				}
+			}
+			if matched || additional || (nested && unevaluated) {
+				seen.props[child.Key] = true
+			}
+		}
+	case parser.TypeArray:
+		prefix := 0
+		if items, ok := m["prefixItems"].([]any); ok {
+			prefix = len(items)
+		}
+		_, items := m["items"]
+		_, unevaluated := m["unevaluatedItems"]
+		contains, hasContains := m["contains"]
+		for i, child := range node.Children {
+			switch {
+			case i < prefix, items, nested && unevaluated:
+				seen.items[i] = true
+			case hasContains && v.valid(child, contains, "", 0):
+				seen.items[i] = true
+			}
+		}
+	}
+}
+
+// resolvePointer follows a JSON Pointer fragment such as "/$defs/item"
+// from v; an empty fragment is v itself.
+func resolvePointer(v any, fragment string) (any, error) {
+	if fragment == "" {
+		return v, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("invalid pointer %q", fragment)
+	}
+	cur := v
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch typed := cur.(type) {
+		case map[string]any:
+			next, ok := typed[token]
+			if !ok {
+				return nil, fmt.Errorf("no %q", token)
+			}
+			cur = next
+		case []any:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(typed) {
+				return nil, fmt.Errorf("no index %q", token)
+			}
+			cur = typed[idx]
+		default:
+			return nil, fmt.Errorf("no %q", token)
+		}
+	}
+	return cur, nil
+}
+
+func (s *Schema) regexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := s.regexps[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.regexps[pattern] = re
+	return re, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func ratOf(v any) (*big.Rat, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).SetString(n.String())
+}
+
+// intOf returns v as an int, clamped to the range of int so that limits
+// such as a huge maxLength do not wrap around.
+func intOf(v any) (int, bool) {
+	r, ok := ratOf(v)
+	if !ok || !r.IsInt() {
+		return 0, false
+	}
+	n := r.Num()
+	switch {
+	case n.Cmp(big.NewInt(math.MaxInt)) > 0:
+		return math.MaxInt, true
+	case n.Cmp(big.NewInt(math.MinInt)) < 0:
+		return math.MinInt, true
+	}
+	return int(n.Int64()), true
+}
+
+func compactJSON(v any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,163 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/simota/jv/internal/parser"
+)
+
+func check(t *testing.T, schema, doc string) []Violation {
+	t.Helper()
+	s, err := Compile(strings.NewReader(schema))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	root, err := parser.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return s.Validate(root)
+}
+
+func TestDeepRecursiveData(t *testing.T) {
+	const schema = `{
+		"type": "object",
+		"properties": {"value": {"type": "number"}, "next": {"$ref": "#/$defs/node"}},
+		"$defs": {"node": {"anyOf": [{"type": "null"}, {"$ref": "#"}]}}
+	}`
+	doc := "null"
+	for i := 0; i < 200; i++ {
+		doc = `{"value":1,"next":` + doc + `}`
+	}
+	if got := check(t, schema, doc); len(got) != 0 {
+		t.Fatalf("valid list rejected: %v", got[0])
+	}
+	bad := strings.Replace(doc, `"value":1,"next":null`, `"value":"x","next":null`, 1)
+	if got := check(t, schema, bad); len(got) == 0 {
+		t.Fatal("invalid list accepted")
+	}
+}
+
+func TestReferenceCycle(t *testing.T) {
+	got := check(t, `{"$defs": {"a": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`, `1`)
+	if len(got) != 1 || !strings.Contains(got[0].Message, "reference depth exceeded") {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestUnevaluated(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+		valid  bool
+	}{
+		{"properties", `{"properties": {"a": true}, "unevaluatedProperties": false}`, `{"a":1}`, true},
+		{"extra property", `{"properties": {"a": true}, "unevaluatedProperties": false}`, `{"a":1,"b":2}`, false},
+		{"allOf", `{"allOf": [{"properties": {"a": true}}], "properties": {"b": true}, "unevaluatedProperties": false}`, `{"a":1,"b":2}`, true},
+		{"failed anyOf branch", `{"anyOf": [{"properties": {"a": {"type": "string"}}}, true], "unevaluatedProperties": false}`, `{"a":1}`, false},
+		{"ref", `{"$defs": {"d": {"properties": {"a": true}}}, "$ref": "#/$defs/d", "unevaluatedProperties": false}`, `{"a":1}`, true},
+		{"schema", `{"unevaluatedProperties": {"type": "number"}}`, `{"a":"x"}`, false},
+		{"dependentSchemas", `{"dependentSchemas": {"a": {"properties": {"b": true}}}, "properties": {"a": true}, "unevaluatedProperties": false}`, `{"a":1,"b":2}`, true},
+		{"prefixItems", `{"prefixItems": [true], "unevaluatedItems": false}`, `[1]`, true},
+		{"extra item", `{"prefixItems": [true], "unevaluatedItems": false}`, `[1,2]`, false},
+		{"contains", `{"contains": {"type": "string"}, "unevaluatedItems": {"type": "number"}}`, `["a",1]`, true},
+		{"item schema", `{"contains": {"type": "string"}, "unevaluatedItems": {"type": "number"}}`, `["a",true]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := check(t, tt.schema, tt.doc)
+			if (len(got) == 0) != tt.valid {
+				t.Fatalf("valid = %v, violations %v", len(got) == 0, got)
+			}
+		})
+	}
+}
+
+func TestDependentSchemas(t *testing.T) {
+	const schema = `{"dependentSchemas": {"card": {"required": ["billing"]}}}`
+	if got := check(t, schema, `{"card":1}`); len(got) != 1 || got[0].Keyword != "required" {
+		t.Fatalf("got %v", got)
+	}
+	if got := check(t, schema, `{"name":1}`); len(got) != 0 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestIDReference(t *testing.T) {
+	const schema = `{
+		"$id": "https://example.com/root.json",
+		"properties": {"item": {"$ref": "item.json"}, "again": {"$ref": "https://example.com/root.json#/properties/item"}},
+		"$defs": {"item": {"$id": "item.json", "type": "string"}}
+	}`
+	if got := check(t, schema, `{"item":"a","again":"b"}`); len(got) != 0 {
+		t.Fatalf("got %v", got)
+	}
+	if got := check(t, schema, `{"item":1,"again":2}`); len(got) != 2 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestUnsupportedKeyword(t *testing.T) {
+	_, err := Compile(strings.NewReader(`{"properties": {"a": {"$dynamicRef": "#node"}}}`))
+	if err == nil || !strings.Contains(err.Error(), "unsupported keyword $dynamicRef") {
+		t.Fatalf("err = %v", err)
+	}
+	// A property that happens to be named like a keyword is fine.
+	if _, err := Compile(strings.NewReader(`{"properties": {"$dynamicRef": true}}`)); err != nil {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestEmbeddedResource(t *testing.T) {
+	// "#/$defs/n" is relative to the embedded resource, not the root.
+	const schema = `{
+		"$id": "https://ex.com/root.json",
+		"properties": {
+			"x": {"$id": "https://ex.com/sub/item.json", "$defs": {"n": {"type": "number"}}, "$ref": "#/$defs/n"},
+			"y": {"$ref": "sub/item.json"}
+		}
+	}`
+	if got := check(t, schema, `{"x":1,"y":2}`); len(got) != 0 {
+		t.Fatalf("got %v", got)
+	}
+	if got := check(t, schema, `{"x":"a","y":"b"}`); len(got) != 2 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestRelativeResourceRef(t *testing.T) {
+	const schema = `{
+		"$id": "https://ex.com/root.json",
+		"properties": {"p": {"$ref": "sub/other.json"}, "q": {"$ref": "sub/other.json#pos"}},
+		"$defs": {"other": {"$id": "sub/other.json", "$anchor": "pos", "minimum": 0}}
+	}`
+	if got := check(t, schema, `{"p":1,"q":2}`); len(got) != 0 {
+		t.Fatalf("got %v", got)
+	}
+	if got := check(t, schema, `{"p":-1,"q":-2}`); len(got) != 2 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestBrokenReference(t *testing.T) {
+	for _, schema := range []string{
+		`{"$ref": "#/$defs/missing"}`,
+		`{"properties": {"a": {"$ref": "#nope"}}}`,
+		`{"$ref": "https://elsewhere.example/schema.json"}`,
+	} {
+		if _, err := Compile(strings.NewReader(schema)); err == nil {
+			t.Errorf("%s: compiled", schema)
+		}
+	}
+}
+
+func TestHugeLimits(t *testing.T) {
+	if got := check(t, `{"maxLength": 100000000000000000000, "maxItems": 18446744073709551617}`, `"abc"`); len(got) != 0 {
+		t.Fatalf("got %v", got)
+	}
+	if got := check(t, `{"minLength": 18446744073709551617}`, `"abc"`); len(got) != 1 {
+		t.Fatalf("got %v", got)
+	}
+}